@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/rskv-p/jtask/pkg/x_artifact"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_watch"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	artifactsJSON bool   // Print manifests as JSON
+	artifactsOut  string // Directory artifacts are copied to
+)
+
+//
+// ---------- Command Definitions ----------
+
+// artifactsCmd groups the commands on the artifacts kept with runs.
+var artifactsCmd = &cobra.Command{
+	Use:   "artifacts",
+	Short: "List and download the artifacts of runs",
+	Long: "Tasks with `artifacts` globs keep the matching files with the run, in space/runs/<run-id>/artifacts/<task>/, " +
+		"together with a manifest of their sizes and SHA-256 checksums.",
+}
+
+// artifactsListCmd lists the artifacts of a run.
+var artifactsListCmd = &cobra.Command{
+	Use:               "list [run-id]",
+	Short:             "List the artifacts of a run",
+	Long:              "List the artifacts of every task of a run with their size and SHA-256. Without a run ID, list the latest run.",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRuns,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := loadRun(args)
+		if err != nil {
+			return err
+		}
+		artifacts, err := x_run.Artifacts(cfg.RunsDir(), state.ID)
+		if err != nil {
+			return err
+		}
+
+		if artifactsJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(artifacts)
+		}
+		if countArtifacts(artifacts) == 0 {
+			fmt.Println("No artifacts found.")
+			return nil
+		}
+		fmt.Println(renderArtifacts(state, artifacts))
+		return nil
+	},
+}
+
+// artifactsGetCmd copies artifacts of a run out of the run store.
+var artifactsGetCmd = &cobra.Command{
+	Use:   "get <run-id> [task] [file...]",
+	Short: "Copy the artifacts of a run to a directory",
+	Long: "Copy artifacts of a run to the --output directory and verify them against their SHA-256. " +
+		"Without a task, every artifact is copied to <output>/<task>/; with a task, its artifacts are copied " +
+		"to <output>/, optionally only the files matching the given paths or globs.",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeArtifacts,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		state, err := x_run.Load(cfg.RunsDir(), args[0])
+		if err != nil {
+			return err
+		}
+		artifacts, err := x_run.Artifacts(cfg.RunsDir(), state.ID)
+		if err != nil {
+			return err
+		}
+
+		tasks, files := sortedTasks(artifacts), []string(nil)
+		if len(args) > 1 {
+			if _, ok := artifacts[args[1]]; !ok {
+				return fmt.Errorf("task %q has no artifacts in run %s", args[1], state.ID)
+			}
+			tasks, files = []string{args[1]}, args[2:]
+		}
+
+		copied := 0
+		for _, task := range tasks {
+			dest := filepath.Join(artifactsOut, x_artifact.DirName(task))
+			if len(args) > 1 {
+				dest = artifactsOut
+			}
+			for _, f := range artifacts[task] {
+				if !matchAny(files, f.Path) {
+					continue
+				}
+				if err := x_artifact.Copy(f, x_run.ArtifactDir(cfg.RunsDir(), state.ID, task), dest); err != nil {
+					return err
+				}
+				copied++
+			}
+		}
+		if copied == 0 {
+			return fmt.Errorf("no matching artifacts in run %s", state.ID)
+		}
+		fmt.Printf("Copied %d artifact(s) to %s.\n", copied, artifactsOut)
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	artifactsListCmd.Flags().BoolVar(&artifactsJSON, "json", false, "Print the manifests as JSON")
+	artifactsGetCmd.Flags().StringVarP(&artifactsOut, "output", "o", ".", "Directory to copy the artifacts to")
+	artifactsGetCmd.MarkFlagDirname("output")
+
+	artifactsCmd.AddCommand(artifactsListCmd, artifactsGetCmd)
+	rootCmd.AddCommand(artifactsCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// loadRun loads the run given in args, or the latest run if args is empty.
+func loadRun(args []string) (*x_run.State, error) {
+	if len(args) == 1 {
+		return x_run.Load(cfg.RunsDir(), args[0])
+	}
+	latest, err := x_run.Find(cfg.RunsDir(), x_run.Query{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 {
+		return nil, fmt.Errorf("no runs found")
+	}
+	return latest[0], nil
+}
+
+// completeArtifacts completes the run ID, task and files of jt artifacts get.
+func completeArtifacts(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeRuns(cmd, args, toComplete)
+	}
+	artifacts, err := x_run.Artifacts(cfg.RunsDir(), args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 1 {
+		return sortedTasks(artifacts), cobra.ShellCompDirectiveNoFileComp
+	}
+	var files []cobra.Completion
+	for _, f := range artifacts[args[1]] {
+		files = append(files, f.Path)
+	}
+	return files, cobra.ShellCompDirectiveNoFileComp
+}
+
+// sortedTasks returns the task names of artifacts in order.
+func sortedTasks(artifacts map[string][]x_artifact.File) []string {
+	tasks := make([]string, 0, len(artifacts))
+	for task := range artifacts {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
+// countArtifacts returns the number of files in artifacts.
+func countArtifacts(artifacts map[string][]x_artifact.File) int {
+	n := 0
+	for _, files := range artifacts {
+		n += len(files)
+	}
+	return n
+}
+
+// matchAny reports whether name matches any of patterns; no patterns match
+// every name.
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if x_watch.Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderArtifacts renders the artifacts of a run as a table, in the task
+// order of the run.
+func renderArtifacts(state *x_run.State, artifacts map[string][]x_artifact.File) string {
+	header := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cell := lipgloss.NewStyle().Padding(0, 1)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		Headers("TASK", "FILE", "SIZE", "SHA256").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return header
+			}
+			return cell
+		})
+	for _, ts := range state.Tasks {
+		for _, f := range artifacts[ts.Name] {
+			t.Row(ts.Name, f.Path, x_artifact.FormatSize(f.Size), f.SHA256[:12])
+		}
+	}
+	return t.Render()
+}
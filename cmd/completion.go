@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// Shell completion is generated by cobra's completion command
+// (jt completion bash|zsh|fish); the functions here provide the values.
+
+//
+// ---------- Completion Functions ----------
+
+// completeTasks completes task names from the --config tasks file, described
+// by their description and tags. Arguments starting with "tag:" complete to
+// tag selectors. Unless all is set, hidden and internal tasks are left out.
+func completeTasks(all bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if strings.HasPrefix(toComplete, "tag:") {
+			var completions []cobra.Completion
+			for _, tag := range collectTags(tasks) {
+				completions = append(completions, "tag:"+tag)
+			}
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []cobra.Completion
+		for _, t := range tasks.Data {
+			if !all && (t.Hidden || t.Internal) || slices.Contains(args, t.Name) {
+				continue
+			}
+			completions = append(completions, cobra.CompletionWithDesc(t.Name, taskSummary(t)))
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeTags completes the values of --tag.
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	tasks, err := x_task.LoadTasks(pathFlag)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return collectTags(tasks), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRuns completes run IDs, newest first, described by collection and status.
+func completeRuns(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	states, err := x_run.List(cfg.RunsDir())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []cobra.Completion
+	for _, s := range states {
+		completions = append(completions, cobra.CompletionWithDesc(s.ID, s.Collection+" "+string(s.Status)))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeStaleLocks completes the names of locks that can be broken.
+func completeStaleLocks(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	infos, err := x_lock.List(cfg.LockDir())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []cobra.Completion
+	for _, info := range infos {
+		if info.Stale() && !slices.Contains(args, info.Name) {
+			completions = append(completions, info.Name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFormats completes a --format flag from a list of formats.
+func completeFormats(formats []string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// ---------- Helper Functions ----------
+
+// collectTags returns the sorted tags used by any task.
+func collectTags(tasks *x_task.TaskCollection) []string {
+	var tags []string
+	for _, t := range tasks.Data {
+		for _, tag := range t.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// taskSummary describes a task in one line for completion menus.
+func taskSummary(t *x_task.Task) string {
+	s := t.Description
+	if len(t.Tags) > 0 {
+		if s != "" {
+			s += " "
+		}
+		s += "[" + strings.Join(t.Tags, ", ") + "]"
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/rskv-p/jtask/pkg/x_cron"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+//
+// ---------- Command Definition ----------
+
+// daemonCmd runs scheduled tasks until interrupted.
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled tasks",
+	Long:  "Run tasks that have a schedule, applying their overlap, catch-up and jitter settings, until interrupted.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return err
+		}
+
+		entries, err := x_cron.Entries(tasks)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No scheduled tasks found.")
+			return nil
+		}
+
+		path, err := filepath.Abs(pathFlag)
+		if err != nil {
+			path = pathFlag
+		}
+
+		// Stop gracefully on Ctrl+C or SIGTERM
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		runner, cancel := newRunner()
+		defer cancel()
+
+		daemon := x_cron.NewDaemon(entries, cfg.ScheduleStatePath(), func(ctx context.Context, t *x_task.Task) error {
+			selected, err := tasks.Resolve([]string{t.Name})
+			if err != nil {
+				return err
+			}
+			var state *x_run.State
+			skipped, err := withCollectionLock(tasks, func() (err error) {
+				state, err = runner.Start(ctx, tasks, path, selected)
+				return err
+			})
+			if err != nil || skipped {
+				return err
+			}
+			if state.Status != x_run.StatusSuccess {
+				return fmt.Errorf("run %s finished with status %s", state.ID, state.Status)
+			}
+			return nil
+		})
+
+		x_log.Info().
+			Int("scheduled", len(entries)).
+			Msg("starting scheduler daemon")
+		fmt.Printf("Running %d scheduled task(s). Press Ctrl+C to stop.\n", len(entries))
+		return daemon.Start(ctx)
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
+	"github.com/rskv-p/jtask/pkg/x_cron"
+	"github.com/rskv-p/jtask/pkg/x_edit"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/rskv-p/jtask/pkg/x_util"
+	"github.com/rskv-p/jtask/pkg/x_validate"
+	"github.com/spf13/cobra"
+)
+
+// rmYes skips the confirmation of rm.
+var rmYes bool
+
+//
+// ---------- Command Definitions ----------
+
+// addCmd adds a task through a form.
+var addCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a task with a form",
+	Long: "Open a form for a new task and append it to the tasks file. The rest of the file is left as written; " +
+		"the previous version is kept as a .bak file.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		data, tasks, err := loadForEdit()
+		if err != nil {
+			return err
+		}
+
+		t := &x_task.Task{}
+		if len(args) == 1 {
+			t.Name = args[0]
+		}
+		if err := runTaskForm(tasks, t, ""); err != nil {
+			return err
+		}
+
+		out, err := x_edit.AddTask(data, t)
+		if err != nil {
+			return err
+		}
+		if err := saveEdited(out); err != nil {
+			return err
+		}
+		fmt.Printf("Added task %s.\n", t.Name)
+		return nil
+	},
+}
+
+// editCmd changes a task through a form pre-filled with its settings.
+var editCmd = &cobra.Command{
+	Use:   "edit [task]",
+	Short: "Edit a task with a form",
+	Long: "Open a form pre-filled with a task and write the changes back to the tasks file. Only the changed " +
+		"values are rewritten, fields the form does not show are kept, and renaming a task updates depends_on " +
+		"entries of other tasks. The previous version is kept as a .bak file.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		data, tasks, err := loadForEdit()
+		if err != nil {
+			return err
+		}
+
+		var name string
+		if len(args) == 1 {
+			name = args[0]
+		} else {
+			var options []huh.Option[string]
+			for _, t := range tasks.Data {
+				options = append(options, huh.NewOption(t.Name, t.Name))
+			}
+			if err := huh.NewSelect[string]().
+				Title("Select a task to edit:").
+				Options(options...).
+				Value(&name).
+				Run(); err != nil {
+				return err
+			}
+		}
+
+		original := tasks.Find(name)
+		if original == nil {
+			return fmt.Errorf("task %q not found", name)
+		}
+		t := *original
+		if err := runTaskForm(tasks, &t, name); err != nil {
+			return err
+		}
+
+		out, err := x_edit.UpdateTask(data, name, &t)
+		if err != nil {
+			return err
+		}
+		if string(out) == string(data) {
+			fmt.Println("No changes.")
+			return nil
+		}
+		if err := saveEdited(out); err != nil {
+			return err
+		}
+		fmt.Printf("Updated task %s.\n", t.Name)
+		return nil
+	},
+}
+
+// rmCmd removes tasks from the tasks file.
+var rmCmd = &cobra.Command{
+	Use:   "rm <task>...",
+	Short: "Remove tasks",
+	Long: "Remove tasks from the tasks file after confirmation. Tasks that others depend on cannot be removed " +
+		"alone. The previous version is kept as a .bak file.",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		data, err := os.ReadFile(pathFlag)
+		if err != nil {
+			return err
+		}
+
+		if !rmYes {
+			if !isatty.IsTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("confirmation needed and stdin is not a terminal: pass --yes")
+			}
+			confirmed := false
+			if err := huh.NewConfirm().
+				Title("Remove " + strings.Join(args, ", ") + "?").
+				Value(&confirmed).
+				Run(); err != nil {
+				return err
+			}
+			if !confirmed {
+				return nil
+			}
+		}
+
+		out := data
+		for _, name := range args {
+			if out, err = x_edit.RemoveTask(out, name); err != nil {
+				return err
+			}
+		}
+		if err := saveEdited(out); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s.\n", strings.Join(args, ", "))
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	rmCmd.Flags().BoolVarP(&rmYes, "yes", "y", false, "Remove without confirmation")
+	addCmd.ValidArgsFunction = cobra.NoFileCompletions
+	editCmd.ValidArgsFunction = completeTasks(true)
+	rmCmd.ValidArgsFunction = completeTasks(true)
+	rootCmd.AddCommand(addCmd, editCmd, rmCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// loadForEdit reads the tasks file for a form-based edit.
+func loadForEdit() ([]byte, *x_task.TaskCollection, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil, nil, fmt.Errorf("editing tasks needs a terminal")
+	}
+	data, err := os.ReadFile(pathFlag)
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks, err := x_task.LoadTasks(pathFlag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, tasks, nil
+}
+
+// saveEdited validates the edited file and saves it with a backup. Errors
+// block saving, except commands missing from PATH on this machine.
+func saveEdited(data []byte) error {
+	diags := x_validate.Check(pathFlag, data)
+	blocking := false
+	for _, d := range diags {
+		if d.Rule == "missing-binary" {
+			d.Severity = x_validate.SeverityWarning
+		}
+		if d.Severity == x_validate.SeverityError {
+			blocking = true
+		}
+		fmt.Println(d)
+	}
+	if blocking {
+		return withExitCode(exitInvalid, fmt.Errorf("not saving %s: the result would be invalid", pathFlag))
+	}
+	return x_edit.Save(pathFlag, data)
+}
+
+// Task flags shown in the form.
+const (
+	flagAsync    = "async"
+	flagSudo     = "sudo"
+	flagPrint    = "print output"
+	flagHidden   = "hidden"
+	flagInternal = "internal"
+)
+
+// runTaskForm lets the user edit t. original is the current name of the
+// task, or empty for a new one. Fields not in the form are left unchanged.
+func runTaskForm(tasks *x_task.TaskCollection, t *x_task.Task, original string) error {
+	name := t.Name
+	description := t.Description
+	execLine := x_util.JoinArgs(t.Exec)
+	tagsLine := strings.Join(t.Tags, ", ")
+	deps := slices.Clone(t.DependsOn)
+	concurrency := t.Concurrency
+	schedule := t.Schedule
+
+	var flags []string
+	for flag, on := range map[string]bool{
+		flagAsync:    t.IsAsync,
+		flagSudo:     t.IsSudo,
+		flagPrint:    t.IsPrintOutput,
+		flagHidden:   t.Hidden,
+		flagInternal: t.Internal,
+	} {
+		if on {
+			flags = append(flags, flag)
+		}
+	}
+
+	fields := []huh.Field{
+		huh.NewInput().
+			Title("Name").
+			Value(&name).
+			Validate(func(s string) error {
+				s = strings.TrimSpace(s)
+				if s == "" {
+					return fmt.Errorf("name is required")
+				}
+				if s != original && tasks.Find(s) != nil {
+					return fmt.Errorf("task %q already exists", s)
+				}
+				return nil
+			}),
+		huh.NewInput().
+			Title("Description").
+			Value(&description),
+		huh.NewInput().
+			Title("Command").
+			Description("Quote arguments like in a shell").
+			Value(&execLine).
+			Validate(func(s string) error {
+				args, err := x_util.SplitArgs(s)
+				if err == nil && len(args) == 0 {
+					err = fmt.Errorf("command is required")
+				}
+				return err
+			}),
+		huh.NewInput().
+			Title("Tags").
+			Description("Comma separated").
+			Value(&tagsLine),
+	}
+
+	var others []huh.Option[string]
+	for _, other := range tasks.Data {
+		if other.Name != original {
+			others = append(others, huh.NewOption(other.Name, other.Name))
+		}
+	}
+	if len(others) > 0 {
+		fields = append(fields, huh.NewMultiSelect[string]().
+			Title("Depends on").
+			Options(others...).
+			Value(&deps))
+	}
+
+	fields = append(fields,
+		huh.NewMultiSelect[string]().
+			Title("Flags").
+			Options(huh.NewOptions(flagAsync, flagSudo, flagPrint, flagHidden, flagInternal)...).
+			Value(&flags),
+		huh.NewSelect[string]().
+			Title("Concurrency").
+			Options(
+				huh.NewOption("none", ""),
+				huh.NewOption("skip if running", "skip"),
+				huh.NewOption("wait for the running one", "wait"),
+				huh.NewOption("fail if running", "fail"),
+			).
+			Value(&concurrency),
+		huh.NewInput().
+			Title("Schedule").
+			Description("Cron expression or @every 10m, empty for none").
+			Value(&schedule).
+			Validate(func(s string) error {
+				if strings.TrimSpace(s) == "" {
+					return nil
+				}
+				_, err := x_cron.Parse(s, t.Timezone)
+				return err
+			}),
+	)
+
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return err
+	}
+
+	t.Name = strings.TrimSpace(name)
+	t.Description = description
+	t.Exec, _ = x_util.SplitArgs(execLine)
+	t.Tags = nil
+	for _, tag := range strings.Split(tagsLine, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+	t.DependsOn = nil
+	if len(deps) > 0 {
+		t.DependsOn = deps
+	}
+	t.IsAsync = slices.Contains(flags, flagAsync)
+	t.IsSudo = slices.Contains(flags, flagSudo)
+	t.IsPrintOutput = slices.Contains(flags, flagPrint)
+	t.Hidden = slices.Contains(flags, flagHidden)
+	t.Internal = slices.Contains(flags, flagInternal)
+	t.Concurrency = concurrency
+	t.Schedule = strings.TrimSpace(schedule)
+	return nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_event"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/spf13/cobra"
+)
+
+// summaryOut receives the human-readable run summary. It moves to stderr
+// when events are streamed to stdout, so that stdout stays machine-readable.
+var summaryOut io.Writer = os.Stdout
+
+//
+// ---------- Events Flag ----------
+
+// eventsValue is the --events jsonl[=path|fd] flag.
+type eventsValue struct {
+	set    bool   // Whether events were requested
+	target string // File path or descriptor number; stdout if empty
+}
+
+func (v *eventsValue) String() string {
+	if !v.set {
+		return ""
+	}
+	if v.target == "" {
+		return "jsonl"
+	}
+	return "jsonl=" + v.target
+}
+
+func (v *eventsValue) Set(s string) error {
+	format, target, _ := strings.Cut(s, "=")
+	if format != "jsonl" {
+		return fmt.Errorf("unknown event format %q (want jsonl, jsonl=path or jsonl=fd)", format)
+	}
+	if strings.Contains(s, "=") && target == "" {
+		return fmt.Errorf("missing path or file descriptor in %q", s)
+	}
+	v.set, v.target = true, target
+	return nil
+}
+
+func (v *eventsValue) Type() string { return "jsonl[=path|fd]" }
+
+// addEventsFlag registers --events on a command that runs tasks.
+func addEventsFlag(cmd *cobra.Command) {
+	cmd.Flags().Var(&eventsFlag, "events",
+		"Stream run events as JSON Lines to stdout (jsonl), a file (jsonl=path) or a file descriptor (jsonl=3)")
+	cmd.RegisterFlagCompletionFunc("events", cobra.FixedCompletions(
+		[]string{"jsonl", "jsonl="}, cobra.ShellCompDirectiveNoSpace|cobra.ShellCompDirectiveNoFileComp))
+}
+
+//
+// ---------- Event Sink ----------
+
+// openEvents opens the sink requested with --events. Without the flag it
+// returns a nil sink. The returned function closes the sink.
+func openEvents() (x_event.Sink, func(), error) {
+	if !eventsFlag.set {
+		return nil, func() {}, nil
+	}
+
+	var out *os.File
+	switch fd, err := strconv.Atoi(eventsFlag.target); {
+	case eventsFlag.target == "":
+		out = os.Stdout
+		summaryOut = os.Stderr
+	case err == nil:
+		out = os.NewFile(uintptr(fd), "fd"+eventsFlag.target)
+		if out == nil {
+			return nil, nil, fmt.Errorf("invalid file descriptor %d for events", fd)
+		}
+		if _, err := out.Stat(); err != nil {
+			return nil, nil, fmt.Errorf("cannot write events to file descriptor %d: %w", fd, err)
+		}
+	default:
+		f, err := os.Create(eventsFlag.target)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot write events: %w", err)
+		}
+		out = f
+	}
+
+	w := x_event.NewWriter(out)
+	return w, func() {
+		if err := w.Err(); err != nil {
+			x_log.Warn().
+				Err(err).
+				Str("events", eventsFlag.String()).
+				Msg("failed to write events")
+		}
+		if out != os.Stdout {
+			out.Close()
+		}
+	}, nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/charmbracelet/huh"
+)
+
+// ---------- Exit Codes ----------
+
+// Process exit codes of jt, documented in the README for CI.
+const (
+	exitOK         = 0   // All selected tasks succeeded
+	exitFailed     = 1   // A task failed, or was skipped because a dependency failed
+	exitConfig     = 2   // Bad flags, config, tasks file or selection; the default for other errors
+	exitInvalid    = 3   // The tasks file failed validation
+	exitRegression = 4   // --fail-on-regression and a task ran slower than its duration baseline
+	exitCancelled  = 130 // Interrupted by Ctrl+C or SIGTERM
+)
+
+// exitError carries the exit code of a failed command.
+type exitError struct {
+	code int   // Process exit code
+	err  error // Reported error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode attaches an exit code to err. A nil err stays nil.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code for an error returned by a command.
+func exitCode(err error) int {
+	var ee *exitError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &ee):
+		return ee.code
+	default:
+		return exitConfig
+	}
+}
+
+// promptError returns the error of an aborted or failed selection prompt.
+func promptError(err error) error {
+	if errors.Is(err, huh.ErrUserAborted) {
+		return withExitCode(exitCancelled, err)
+	}
+	return withExitCode(exitConfig, err)
+}
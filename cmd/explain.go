@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rskv-p/jtask/pkg/x_plan"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// explainFormat is the output format of explain.
+var explainFormat string
+
+//
+// ---------- Command Definition ----------
+
+// explainCmd prints the execution plan of a run without executing it.
+var explainCmd = &cobra.Command{
+	Use:   "explain [task|selector]...",
+	Short: "Show what a run would do without executing it",
+	Long: "Resolve the tasks given like for run and print the plan: dependency order, the exact argv " +
+		"including sudo, the resolved executable, working directory, environment and the locks the run " +
+		"would take, with whether a held lock would skip a task. Nothing is executed. " +
+		"jt run --dry-run prints the same plan.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return withExitCode(exitConfig, err)
+		}
+
+		selected, err := selectFromArgs(tasks, args)
+		if err != nil {
+			return withExitCode(exitConfig, err)
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no tasks selected: pass task names, selectors or --tag")
+		}
+		return explainRun(tasks, selected, explainFormat)
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	explainCmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "Select tasks with this tag (repeatable)")
+	explainCmd.Flags().StringVarP(&explainFormat, "format", "f", "text", "Output format: text or json")
+	explainCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	explainCmd.RegisterFlagCompletionFunc("format", completeFormats(x_plan.Formats))
+	explainCmd.ValidArgsFunction = completeTasks(false)
+	rootCmd.AddCommand(explainCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// explainRun prints the plan of running the named tasks and their dependencies.
+func explainRun(tasks *x_task.TaskCollection, names []string, format string) error {
+	selected, err := tasks.Resolve(names)
+	if err != nil {
+		return resolveError(err)
+	}
+
+	path, err := filepath.Abs(pathFlag)
+	if err != nil {
+		path = pathFlag
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	plan, err := x_plan.Build(tasks, selected, path, dir, cfg.LockDir(), maxConcurrent())
+	if err != nil {
+		return err
+	}
+	return plan.Render(os.Stdout, format)
+}
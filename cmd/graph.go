@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rskv-p/jtask/pkg/x_graph"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// graphFormat is the output format of graph.
+var graphFormat string
+
+//
+// ---------- Command Definition ----------
+
+// graphCmd renders the dependency graph of the tasks file.
+var graphCmd = &cobra.Command{
+	Use:   "graph [task]...",
+	Short: "Show the task dependency graph",
+	Long: "Render the dependency graph of all tasks, or of the given tasks and their dependencies, " +
+		"as terminal levels (ascii), Graphviz DOT or Mermaid. Tasks are colored by the status of their " +
+		"last run and the critical path, the slowest chain of dependencies, is highlighted.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return err
+		}
+
+		names := args
+		if len(names) == 0 {
+			for _, t := range tasks.Data {
+				names = append(names, t.Name)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("No tasks found.")
+			return nil
+		}
+		selected, err := tasks.Resolve(names)
+		if err != nil {
+			return err
+		}
+
+		// Color by the last runs of this collection
+		runs, err := x_run.List(cfg.RunsDir())
+		if err != nil {
+			return err
+		}
+		var own []*x_run.State
+		for _, s := range runs {
+			if s.Collection == tasks.Name {
+				own = append(own, s)
+			}
+		}
+
+		return x_graph.New(selected, x_run.Latest(own)).Render(os.Stdout, graphFormat)
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "ascii", "Output format: ascii, dot or mermaid")
+	graphCmd.RegisterFlagCompletionFunc("format", completeFormats(x_graph.Formats))
+	graphCmd.ValidArgsFunction = completeTasks(true)
+	rootCmd.AddCommand(graphCmd)
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/rskv-p/jtask/pkg/x_artifact"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_store"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	historyTask   string // Only runs with a task matching this glob
+	historyStatus string // Only runs with this overall status
+	historySince  string // Only runs started after this time
+	historyLimit  int    // Max number of runs to list
+	historyJSON   bool   // Print runs as JSON
+	showJSON      bool   // Print the run as JSON
+	pruneOlder    string // Drop runs older than this
+	pruneKeep     int    // Keep only this many of the newest runs
+)
+
+//
+// ---------- Command Definitions ----------
+
+// historyCmd lists past runs from the runs directory.
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past runs",
+	Long: "List past runs, newest first, with their start time, user, tasks, status and duration. " +
+		"Filter with --task, --status and --since; see a run in detail with jt show.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		status, err := x_run.ParseStatus(historyStatus)
+		if err != nil {
+			return err
+		}
+		q := x_run.Query{Task: historyTask, Status: status, Limit: historyLimit}
+		if historySince != "" {
+			if q.Since, err = x_run.ParseSince(historySince, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		states, err := x_run.Find(cfg.RunsDir(), q)
+		if err != nil {
+			return err
+		}
+		if historyJSON {
+			if states == nil {
+				states = []*x_run.State{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(states)
+		}
+		if len(states) == 0 {
+			fmt.Println("No runs found.")
+			return nil
+		}
+		fmt.Println(renderHistory(states))
+		return nil
+	},
+}
+
+// showCmd prints one run with the results and output of its tasks.
+var showCmd = &cobra.Command{
+	Use:   "show [run-id]",
+	Short: "Show the results of a run",
+	Long: "Show a run with the status, duration, exit code, error and captured output of every task, and the artifacts it kept. " +
+		"Without a run ID, show the latest run.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := loadRun(args)
+		if err != nil {
+			return err
+		}
+
+		if err := writeReports(state); err != nil {
+			return err
+		}
+		if showJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(state)
+		}
+		artifacts, err := x_run.Artifacts(cfg.RunsDir(), state.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Print(renderRun(state, artifacts))
+		return nil
+	},
+}
+
+// historyPruneCmd drops old runs from the run store.
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Drop old runs from the run store",
+	Long: "Drop runs older than --older-than or beyond the newest --keep runs, and compact the run store. " +
+		"Without flags, apply the Retention of the config, which jt also applies after every run.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r := retention()
+		if cmd.Flags().Changed("older-than") || cmd.Flags().Changed("keep") {
+			r = x_store.Retention{MaxRuns: pruneKeep}
+			if pruneOlder != "" {
+				now := time.Now()
+				since, err := x_run.ParseSince(pruneOlder, now)
+				if err != nil {
+					return err
+				}
+				r.MaxAge = now.Sub(since)
+			}
+		}
+		if r == (x_store.Retention{}) {
+			return fmt.Errorf("no retention given: use --older-than or --keep")
+		}
+
+		dropped, err := x_run.Prune(cfg.RunsDir(), r)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Pruned %d run(s).\n", len(dropped))
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	historyCmd.Flags().StringVar(&historyTask, "task", "", "Only runs with a task matching this name or glob")
+	historyCmd.Flags().StringVar(&historyStatus, "status", "", "Only runs with this status: success, failed or running")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only runs started since a duration ago (24h, 7d) or a date")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Max number of runs to list (0 for all)")
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Print runs as JSON")
+	historyCmd.RegisterFlagCompletionFunc("task", completeTasks(true))
+	historyCmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(
+		[]string{string(x_run.StatusSuccess), string(x_run.StatusFailed), string(x_run.StatusRunning)},
+		cobra.ShellCompDirectiveNoFileComp))
+
+	historyPruneCmd.Flags().StringVar(&pruneOlder, "older-than", "", "Drop runs older than a duration (30d, 12h) or a date")
+	historyPruneCmd.Flags().IntVar(&pruneKeep, "keep", 0, "Keep only this many of the newest runs")
+	historyCmd.AddCommand(historyPruneCmd)
+
+	showCmd.Flags().BoolVar(&showJSON, "json", false, "Print the run as JSON")
+	showCmd.ValidArgsFunction = completeRuns
+	addReportFlag(showCmd)
+
+	rootCmd.AddCommand(historyCmd, showCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// runTasks summarizes the task names of a run, e.g. "build, test, +2 more".
+func runTasks(state *x_run.State) string {
+	const shown = 3
+	var names []string
+	for i, ts := range state.Tasks {
+		if i == shown {
+			names = append(names, fmt.Sprintf("+%d more", len(state.Tasks)-shown))
+			break
+		}
+		names = append(names, ts.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// renderHistory renders runs as a table.
+func renderHistory(states []*x_run.State) string {
+	header := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cell := lipgloss.NewStyle().Padding(0, 1)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		Headers("RUN", "STARTED", "USER", "TASKS", "STATUS", "DURATION").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return header
+			case col == 4:
+				return cell.Foreground(statusColors[states[row].Status])
+			default:
+				return cell
+			}
+		})
+	for _, s := range states {
+		t.Row(
+			s.ID,
+			s.Created.Local().Format("2006-01-02 15:04:05"),
+			s.User,
+			runTasks(s),
+			string(s.Status),
+			s.Duration().Round(time.Millisecond).String(),
+		)
+	}
+	return t.Render()
+}
+
+// renderRun renders a run with its summary table, the error and output
+// of every task that has any, and its artifacts.
+func renderRun(state *x_run.State, artifacts map[string][]x_artifact.File) string {
+	label := lipgloss.NewStyle().Bold(true)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s\n", label.Render("Run:       "), state.ID)
+	fmt.Fprintf(&b, "%s %s (%s)\n", label.Render("Tasks file:"), state.TasksFile, state.Collection)
+	fmt.Fprintf(&b, "%s %s\n", label.Render("User:      "), state.User)
+	fmt.Fprintf(&b, "%s %s\n", label.Render("Started:   "), state.Created.Local().Format(time.RFC3339))
+	fmt.Fprintf(&b, "%s %s in %s\n", label.Render("Status:    "),
+		lipgloss.NewStyle().Foreground(statusColors[state.Status]).Render(string(state.Status)),
+		state.Duration().Round(time.Millisecond))
+	b.WriteString(renderRunSummary(state) + "\n")
+
+	for _, ts := range state.Tasks {
+		if ts.Error == "" && ts.Output == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s\n", label.Render("── "+ts.Name+" ("+string(ts.Status)+") ──"))
+		if ts.Error != "" {
+			fmt.Fprintf(&b, "error: %s\n", ts.Error)
+		}
+		if ts.Output != "" {
+			b.WriteString(ts.Output)
+			if !strings.HasSuffix(ts.Output, "\n") {
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	if countArtifacts(artifacts) > 0 {
+		fmt.Fprintf(&b, "\n%s\n%s\n", label.Render("── artifacts ──"), renderArtifacts(state, artifacts))
+	}
+	return b.String()
+}
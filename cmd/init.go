@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
+	"github.com/rskv-p/jtask/pkg/x_config"
+	"github.com/rskv-p/jtask/pkg/x_scaffold"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	initYes   bool // Accept all proposals without prompting
+	initForce bool // Overwrite existing files without asking
+)
+
+//
+// ---------- Command Definition ----------
+
+// initCmd scaffolds a tasks file and app config for the current project.
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a tasks file for this project",
+	Long: "Detect the project type (go.mod, package.json, Cargo.toml, pyproject.toml, Makefile), " +
+		"propose common build, test, lint and fmt tasks, and write the tasks file and " + x_config.LocalConfig + ". " +
+		"Existing files are only overwritten after confirmation or with --force.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interactive := !initYes && isatty.IsTerminal(os.Stdin.Fd())
+
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(dir)
+
+		projects := x_scaffold.Detect(dir)
+		proposals := x_scaffold.Proposals(projects)
+		if len(projects) == 0 {
+			fmt.Println("No known project type detected, starting with an example task.")
+			proposals = []*x_task.Task{{Name: "hello", Description: "Example task", Exec: []string{"echo", "hello"}}}
+		} else {
+			var kinds []string
+			for _, p := range projects {
+				kinds = append(kinds, p.Kind+" ("+p.Marker+")")
+			}
+			fmt.Println("Detected:", strings.Join(kinds, ", "))
+		}
+
+		// Let the user pick the name and tasks
+		chosen := make([]string, 0, len(proposals))
+		for _, t := range proposals {
+			chosen = append(chosen, t.Name)
+		}
+		writeConfig := true
+		if interactive {
+			var options []huh.Option[string]
+			for _, t := range proposals {
+				label := t.Name + "  " + strings.Join(t.Exec, " ")
+				options = append(options, huh.NewOption(label, t.Name).Selected(true))
+			}
+			if err := huh.NewForm(huh.NewGroup(
+				huh.NewInput().
+					Title("Collection name").
+					Value(&name),
+				huh.NewMultiSelect[string]().
+					Title("Tasks to create").
+					Options(options...).
+					Value(&chosen),
+				huh.NewConfirm().
+					Title("Write app config "+x_config.LocalConfig+"?").
+					Value(&writeConfig),
+			)).Run(); err != nil {
+				return err
+			}
+		}
+
+		collection := &x_task.TaskCollection{Name: name, Description: "Tasks of " + name}
+		for _, t := range proposals {
+			for _, c := range chosen {
+				if c == t.Name {
+					collection.Data = append(collection.Data, t)
+				}
+			}
+		}
+
+		// Write the files, asking before replacing existing ones
+		var refused []string
+		ok, err := confirmOverwrite(pathFlag, interactive)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := x_scaffold.WriteTasks(pathFlag, collection); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s with %d task(s).\n", pathFlag, len(collection.Data))
+		} else {
+			refused = append(refused, pathFlag)
+		}
+
+		if writeConfig {
+			ok, err := confirmOverwrite(x_config.LocalConfig, interactive)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := x_scaffold.AppConfig(name).Write(x_config.LocalConfig); err != nil {
+					return err
+				}
+				fmt.Printf("Wrote %s. Consider adding %s/ to .gitignore.\n", x_config.LocalConfig, x_config.DefaultSpace)
+			} else {
+				refused = append(refused, x_config.LocalConfig)
+			}
+		}
+
+		if len(refused) > 0 && !interactive {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("not overwriting %s: use --force", strings.Join(refused, ", "))
+		}
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept all proposed tasks without prompting")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite existing files without asking")
+	rootCmd.AddCommand(initCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// confirmOverwrite reports whether path may be written: it does not exist,
+// --force is set, or the user confirms. Without a prompt it refuses.
+func confirmOverwrite(path string, interactive bool) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) || initForce {
+		return true, nil
+	}
+	if !interactive {
+		fmt.Printf("%s already exists, keeping it.\n", path)
+		return false, nil
+	}
+
+	overwrite := false
+	if err := huh.NewConfirm().
+		Title(path + " already exists. Overwrite it?").
+		Value(&overwrite).
+		Run(); err != nil {
+		return false, err
+	}
+	if !overwrite {
+		fmt.Printf("Keeping %s.\n", path)
+	}
+	return overwrite, nil
+}
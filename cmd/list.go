@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/lipgloss/tree"
+	"github.com/rskv-p/jtask/pkg/x_select"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	listTree bool // Show namespaces and dependencies as a tree
+	listJSON bool // Print tasks as JSON
+	listAll  bool // Include hidden tasks
+)
+
+//
+// ---------- Command Definition ----------
+
+// listCmd shows the tasks of the tasks file.
+var listCmd = &cobra.Command{
+	Use:     "list [task|selector]...",
+	Aliases: []string{"ls"},
+	Short:   "List tasks",
+	Long: "List tasks with their description, tags, dependencies and flags. " +
+		"Tasks are filtered like for run, by name, glob, --tag or selector expression. " +
+		"Hidden tasks are shown with --all or when named exactly.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listTree && listJSON {
+			return fmt.Errorf("--tree and --json cannot be combined")
+		}
+
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return err
+		}
+
+		listed, err := listTasks(tasks, args)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case listJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(listed)
+		case len(listed) == 0:
+			fmt.Println("No tasks found.")
+		case listTree:
+			fmt.Println(renderTaskTree(tasks, listed))
+		default:
+			fmt.Println(renderTaskTable(listed))
+		}
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "Show namespaces and dependencies as a tree")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print tasks as JSON")
+	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "Include hidden tasks")
+	listCmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "Select tasks with this tag (repeatable)")
+	listCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	listCmd.ValidArgsFunction = completeTasks(true)
+	rootCmd.AddCommand(listCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// listTasks returns the selected tasks in collection order; all tasks without a
+// selection. Hidden tasks are dropped unless --all is set or they are named exactly.
+func listTasks(tasks *x_task.TaskCollection, args []string) ([]*x_task.Task, error) {
+	names, err := x_select.Select(tasks, args, tagFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := []*x_task.Task{}
+	for _, t := range tasks.Data {
+		if names != nil && !slices.Contains(names, t.Name) {
+			continue
+		}
+		if t.Hidden && !listAll && !slices.Contains(args, t.Name) {
+			continue
+		}
+		listed = append(listed, t)
+	}
+	return listed, nil
+}
+
+// taskFlags returns the markers of a task, e.g. "async, sudo".
+func taskFlags(t *x_task.Task) string {
+	var flags []string
+	if t.IsAsync {
+		flags = append(flags, "async")
+	}
+	if t.IsSudo {
+		flags = append(flags, "sudo")
+	}
+	if t.Hidden {
+		flags = append(flags, "hidden")
+	}
+	if t.Internal {
+		flags = append(flags, "internal")
+	}
+	return strings.Join(flags, ", ")
+}
+
+// renderTaskTable renders tasks as a table; hidden and internal tasks are dimmed.
+func renderTaskTable(listed []*x_task.Task) string {
+	header := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cell := lipgloss.NewStyle().Padding(0, 1)
+	dim := cell.Faint(true)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		Headers("NAME", "DESCRIPTION", "TAGS", "DEPENDS ON", "FLAGS").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return header
+			case listed[row].Hidden || listed[row].Internal:
+				return dim
+			default:
+				return cell
+			}
+		})
+	for _, task := range listed {
+		t.Row(
+			task.Name,
+			task.Description,
+			strings.Join(task.Tags, ", "),
+			strings.Join(task.DependsOn, ", "),
+			taskFlags(task),
+		)
+	}
+	return t.Render()
+}
+
+// renderTaskTree renders tasks grouped by their ":" namespaces, with the
+// dependencies of every task nested below it.
+func renderTaskTree(tasks *x_task.TaskCollection, listed []*x_task.Task) string {
+	title := tasks.Name
+	if title == "" {
+		title = pathFlag
+	}
+	root := tree.Root(title)
+
+	// Namespace nodes by prefix; a task named like a namespace reuses its node
+	nodes := make(map[string]*tree.Tree)
+	var node func(name string) *tree.Tree
+	node = func(name string) *tree.Tree {
+		if n, ok := nodes[name]; ok {
+			return n
+		}
+		parent, last := root, name
+		if i := strings.LastIndex(name, ":"); i > 0 {
+			parent, last = node(name[:i]), name[i+1:]
+		}
+		n := tree.Root(last + ":")
+		parent.Child(n)
+		nodes[name] = n
+		return n
+	}
+
+	for _, t := range listed {
+		n := node(t.Name)
+		label := t.Name[strings.LastIndex(t.Name, ":")+1:]
+		if t.Description != "" {
+			label += " - " + t.Description
+		}
+		if flags := taskFlags(t); flags != "" {
+			label += " [" + flags + "]"
+		}
+		n.Root(label)
+		for _, dep := range t.DependsOn {
+			n.Child(dependencyTree(tasks, dep, []string{t.Name}))
+		}
+	}
+	return root.String()
+}
+
+// dependencyTree returns a node for a dependency and its own dependencies.
+// path holds the tasks above it and stops cycles.
+func dependencyTree(tasks *x_task.TaskCollection, name string, path []string) *tree.Tree {
+	t := tasks.Find(name)
+	switch {
+	case t == nil:
+		return tree.Root("→ " + name + " (missing)")
+	case slices.Contains(path, name):
+		return tree.Root("→ " + name + " (cycle)")
+	}
+
+	n := tree.Root("→ " + name)
+	for _, dep := range t.DependsOn {
+		n.Child(dependencyTree(tasks, dep, append(path, name)))
+	}
+	return n
+}
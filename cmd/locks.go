@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/spf13/cobra"
+)
+
+//
+// ---------- Command Definition ----------
+
+// locksCmd lists task and collection locks with their owners.
+var locksCmd = &cobra.Command{
+	Use:   "locks",
+	Short: "Show task and collection locks",
+	Long:  "List lock files under the space directory with the PID, host and start time of their owner.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		infos, err := x_lock.List(cfg.LockDir())
+		if err != nil {
+			x_log.Error().
+				Err(err).
+				Str("dir", cfg.LockDir()).
+				Msg("failed to list locks")
+			return err
+		}
+
+		if len(infos) == 0 {
+			fmt.Println("No locks found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LOCK\tSTATE\tPID\tHOST\tSTARTED")
+		for _, info := range infos {
+			state := "held"
+			if info.Stale() {
+				state = "stale"
+			}
+			pid, host, started := "-", "-", "-"
+			if info.Owner != nil {
+				pid = fmt.Sprint(info.Owner.PID)
+				host = info.Owner.Host
+				started = info.Owner.Started.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Name, state, pid, host, started)
+		}
+		return w.Flush()
+	},
+}
+
+// locksBreakCmd removes stale locks left behind by crashed processes.
+var locksBreakCmd = &cobra.Command{
+	Use:   "break [lock...]",
+	Short: "Remove stale locks",
+	Long:  "Remove stale lock files. Locks still held by a running process are never removed.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names := args
+		if len(names) == 0 {
+			// Without arguments break every stale lock
+			infos, err := x_lock.List(cfg.LockDir())
+			if err != nil {
+				return err
+			}
+			for _, info := range infos {
+				if info.Stale() {
+					names = append(names, info.Name)
+				}
+			}
+		}
+
+		if len(names) == 0 {
+			fmt.Println("No stale locks found.")
+			return nil
+		}
+
+		var failed bool
+		for _, name := range names {
+			if err := x_lock.Break(cfg.LockDir(), name); err != nil {
+				x_log.Warn().
+					Err(err).
+					Str("lock", name).
+					Msg("failed to break lock")
+				fmt.Printf("Cannot break %s: %v\n", name, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("Broke stale lock %s\n", name)
+		}
+		if failed {
+			return fmt.Errorf("some locks could not be broken")
+		}
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	locksBreakCmd.ValidArgsFunction = completeStaleLocks
+	locksCmd.AddCommand(locksBreakCmd)
+	rootCmd.AddCommand(locksCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// acquireLock takes a lock according to a policy from the tasks file.
+// skip is true when the policy is "skip" and the lock is held elsewhere.
+func acquireLock(name, policy string) (lock *x_lock.Lock, skip bool, err error) {
+	p, err := x_lock.ParsePolicy(policy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	lock, err = x_lock.Acquire(cfg.LockDir(), name, p)
+	if errors.Is(err, x_lock.ErrLocked) && p == x_lock.PolicySkip {
+		x_log.Info().
+			Err(err).
+			Str("lock", name).
+			Msg("lock is held, skipping")
+		return nil, true, nil
+	}
+	return lock, false, err
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	logsFollow bool // Keep printing output until the run finishes
+	logsTail   int  // Only the last lines of every log
+)
+
+// logColors are the terminal colors of task name prefixes, in turn.
+var logColors = []lipgloss.Color{"6", "5", "4", "3", "2"}
+
+//
+// ---------- Command Definition ----------
+
+// logsCmd prints the output of the tasks of a run.
+var logsCmd = &cobra.Command{
+	Use:   "logs <run-id> [task...]",
+	Short: "Print the output of the tasks of a run",
+	Long: "Print the raw output of the tasks of a run from space/runs/<run-id>/<task>.log, task by task. " +
+		"With several tasks every line is prefixed with its task. --follow keeps printing new output " +
+		"until the run finishes, also for runs executed by another jt process, and stops early if the process " +
+		"executing the run died.",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeRunTasks,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		state, err := x_run.Load(cfg.RunsDir(), args[0])
+		if err != nil {
+			return err
+		}
+		tasks := args[1:]
+		if len(tasks) == 0 {
+			for _, ts := range state.Tasks {
+				tasks = append(tasks, ts.Name)
+			}
+		}
+
+		// Prefix lines with their task when several tasks are printed
+		prefixes := make(map[string]string)
+		if len(tasks) > 1 {
+			width := 0
+			for _, task := range tasks {
+				width = max(width, lipgloss.Width(task))
+			}
+			for i, task := range tasks {
+				style := lipgloss.NewStyle().Foreground(logColors[i%len(logColors)]).Width(width)
+				prefixes[task] = style.Render(task) + " | "
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		opts := x_run.LogOptions{Tasks: tasks, Tail: logsTail, Follow: logsFollow}
+		if err := x_run.Logs(ctx, cfg.RunsDir(), state.ID, opts, func(task, line string) {
+			fmt.Println(prefixes[task] + line)
+		}); err != nil {
+			return err
+		}
+
+		// A run still marked running that no process executes was interrupted
+		if logsFollow && ctx.Err() == nil {
+			if state, err := x_run.Load(cfg.RunsDir(), state.ID); err == nil && state.Status == x_run.StatusRunning {
+				fmt.Fprintf(os.Stderr, "Run %s was interrupted. Resume it with: jt resume %s\n", state.ID, state.ID)
+			}
+		}
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new output until the run finishes")
+	logsCmd.Flags().IntVarP(&logsTail, "tail", "n", 0, "Only the last N lines of every task (0 for all)")
+
+	rootCmd.AddCommand(logsCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// completeRunTasks completes a run ID, then the tasks of that run.
+func completeRunTasks(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeRuns(cmd, args, toComplete)
+	}
+	state, err := x_run.Load(cfg.RunsDir(), args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var tasks []cobra.Completion
+	for _, ts := range state.Tasks {
+		tasks = append(tasks, cobra.CompletionWithDesc(ts.Name, string(ts.Status)))
+	}
+	return tasks, cobra.ShellCompDirectiveNoFileComp
+}
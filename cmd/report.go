@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_report"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/spf13/cobra"
+)
+
+//
+// ---------- Report Flag ----------
+
+// reportsValue is the repeatable --report format=path flag. Values are
+// checked when the flag is parsed, so a typo fails before tasks run.
+type reportsValue []x_report.Spec
+
+func (v *reportsValue) String() string {
+	var specs []string
+	for _, s := range *v {
+		specs = append(specs, s.Format+"="+s.Path)
+	}
+	return strings.Join(specs, ",")
+}
+
+func (v *reportsValue) Set(s string) error {
+	spec, err := x_report.ParseSpec(s)
+	if err != nil {
+		return err
+	}
+	*v = append(*v, spec)
+	return nil
+}
+
+func (v *reportsValue) Type() string { return "format=path" }
+
+// traceValue is the --trace path flag, short for --report trace=path.
+type traceValue struct{ reports *reportsValue }
+
+func (v traceValue) String() string { return "" }
+
+func (v traceValue) Set(s string) error { return v.reports.Set("trace=" + s) }
+
+func (v traceValue) Type() string { return "path" }
+
+// addReportFlag registers --report on a command that runs or shows a run.
+func addReportFlag(cmd *cobra.Command) {
+	cmd.Flags().Var(&reportFlag, "report",
+		"Write a report of the run as format=path, e.g. junit=report.xml (repeatable; formats: "+
+			strings.Join(x_report.Formats, ", ")+")")
+	cmd.RegisterFlagCompletionFunc("report", completeReports)
+	cmd.Flags().Var(traceValue{&reportFlag}, "trace",
+		"Write a Chrome trace of the run to path, for chrome://tracing or ui.perfetto.dev (same as --report trace=path)")
+}
+
+// completeReports completes the format part of --report.
+func completeReports(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, "=") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	var completions []cobra.Completion
+	for _, f := range x_report.Formats {
+		completions = append(completions, f+"=")
+	}
+	return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+//
+// ---------- Report Writing ----------
+
+// writeReports writes the reports requested with --report for a run. Every
+// report is attempted; the first error is returned.
+func writeReports(state *x_run.State) error {
+	if len(reportFlag) == 0 || state == nil {
+		return nil
+	}
+	report, err := x_report.Load(cfg.RunsDir(), state)
+	if err != nil {
+		return fmt.Errorf("failed to load results of run %s: %w", state.ID, err)
+	}
+
+	var first error
+	for _, spec := range reportFlag {
+		if err := report.Write(spec); err != nil {
+			x_log.Error().
+				Err(err).
+				Str("format", spec.Format).
+				Str("path", spec.Path).
+				Msg("failed to write report")
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		x_log.Info().
+			Str("format", spec.Format).
+			Str("path", spec.Path).
+			Msg("report written")
+	}
+	return first
+}
+
+// withReports writes the reports of a finished run and returns the outcome
+// of the command: a run failure takes precedence over a report failure.
+func withReports(state *x_run.State, outcome error) error {
+	if err := writeReports(state); err != nil && outcome == nil {
+		return withExitCode(exitConfig, err)
+	}
+	return outcome
+}
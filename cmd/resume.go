@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+//
+// ---------- Command Definition ----------
+
+// resumeCmd continues an interrupted or failed run from its checkpoint.
+var resumeCmd = &cobra.Command{
+	Use:   "resume <run-id>",
+	Short: "Resume an interrupted or failed run",
+	Long: "Reload the tasks file of a run, verify that its task definitions have not changed, " +
+		"skip the tasks that already succeeded and continue with the rest.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		state, err := x_run.Load(cfg.RunsDir(), args[0])
+		if err != nil {
+			return err
+		}
+
+		// Reload the tasks file the run was started from, unless overridden
+		path := state.TasksFile
+		if cmd.Flags().Changed("config") || path == "" {
+			path = pathFlag
+		}
+
+		x_log.Info().
+			Str("run", state.ID).
+			Str("path", path).
+			Msg("resuming run")
+
+		tasks, err := x_task.LoadTasks(path)
+		if err != nil {
+			return err
+		}
+
+		events, closeEvents, err := openEvents()
+		if err != nil {
+			return withExitCode(exitConfig, err)
+		}
+		defer closeEvents()
+
+		// Stop gracefully on Ctrl+C or SIGTERM
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		skipped, err := withCollectionLock(tasks, func() error {
+			runner, cancel := newRunner()
+			defer cancel()
+			runner.Events = events
+
+			if err := runner.Resume(ctx, state, tasks); err != nil {
+				return err
+			}
+			reportRun(state)
+			return nil
+		})
+		if errors.Is(err, x_run.ErrDefinitionChanged) {
+			return withExitCode(exitConfig, fmt.Errorf("cannot resume run %s: %w", state.ID, err))
+		}
+		if err != nil {
+			return withExitCode(exitFailed, fmt.Errorf("cannot resume run %s: %w", state.ID, err))
+		}
+		if skipped {
+			return nil
+		}
+		return withReports(state, runOutcome(ctx, state))
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	resumeCmd.ValidArgsFunction = completeRuns
+	addReportFlag(resumeCmd)
+	addEventsFlag(resumeCmd)
+	addRegressionFlag(resumeCmd)
+	rootCmd.AddCommand(resumeCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -10,7 +11,13 @@ import (
 )
 
 // ---------- Global Flag ----------
-var pathFlag string // Global flag for task file path
+var pathFlag string           // Global flag for task file path
+var jobsFlag int              // Global flag overriding MaxConcurrent
+var tagFlags []string         // Tag selection of run and runs
+var dryRunFlag bool           // Print the plan of run and runs instead of executing
+var reportFlag reportsValue   // Reports written after run, runs and resume
+var eventsFlag eventsValue    // Event stream of run, runs and resume
+var failOnRegressionFlag bool // Fail run, runs and resume if a task ran slower than its baseline
 var cfg x_config.Config
 
 // ---------- Root Command Definition ----------
@@ -40,7 +47,7 @@ func Execute() {
 			Err(err).
 			Msg("command execution failed")
 
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	// Log success if the command executes correctly
@@ -49,11 +56,16 @@ func Execute() {
 
 // ---------- Command Initialization ----------
 func init() {
-	cfg, err := x_config.LoadConfig()
+	// Without any config file, run with defaults so that jt init works in new projects
+	loaded, err := x_config.LoadConfig()
+	if errors.Is(err, x_config.ErrNotFound) {
+		loaded, err = x_config.Default(), nil
+	}
 	if err != nil {
 		fmt.Println("Failed to load config:", err)
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
+	cfg = *loaded
 
 	// Apply logger configuration from the config
 	x_log.InitWithConfig(&cfg.Logger, "main")
@@ -65,6 +77,12 @@ func init() {
 	rootCmd.PersistentFlags().
 		StringVarP(&pathFlag, "config", "p", "./tasks.json", "Path to the tasks file")
 
+	rootCmd.MarkPersistentFlagFilename("config", "json")
+
+	// Define the -j flag to override the concurrency limit
+	rootCmd.PersistentFlags().
+		IntVarP(&jobsFlag, "jobs", "j", 0, "Max number of concurrent tasks (overrides MaxConcurrent)")
+
 	// Log the default path of tasks file for debugging
 	x_log.Debug().
 		Str("default_path", "./tasks.json").
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os/exec"
 
 	"github.com/charmbracelet/huh"
 	"github.com/rskv-p/jtask/pkg/x_log"
@@ -13,12 +12,15 @@ import (
 //
 // ---------- Command Definition ----------
 
-// runCmd allows selecting and running a task.
+// runCmd runs the tasks given as arguments, or lets the user select one.
 var runCmd = &cobra.Command{
-	Use:   "run",
-	Short: "Select a task to run",
-	Long:  "Select a task from the list and execute it.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:   "run [task|selector]...",
+	Short: "Run tasks by name or selector, or select one",
+	Long: "Run the tasks given by name, glob (test:*), --tag or selector expression ('tag:ci && !tag:slow'). " +
+		"Without a selection, prompt for a task when stdin is a terminal.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
 		// Load tasks from the config file
 		x_log.Info().
 			Str("path", pathFlag).
@@ -31,8 +33,7 @@ var runCmd = &cobra.Command{
 				Err(err).
 				Str("path", pathFlag).
 				Msg("failed to load tasks")
-			fmt.Println("Error loading tasks:", err)
-			return
+			return withExitCode(exitConfig, err)
 		}
 
 		// Handle case where no tasks are available
@@ -40,130 +41,70 @@ var runCmd = &cobra.Command{
 			x_log.Warn().
 				Msg("no tasks available to select")
 			fmt.Println("No tasks available to select.")
-			return
+			return nil
 		}
 
-		// Build selection options
-		x_log.Info().
-			Int("count", len(tasks.Data)).
-			Msg("building selection options")
-
-		var options []huh.Option[string]
-		for _, task := range tasks.Data {
-			options = append(options, huh.NewOption(task.Name, task.Name))
-		}
-
-		// Prompt user to select a task
-		x_log.Debug().Msg("prompting user to select a task")
-		var selectedTask string
-		if err := huh.NewSelect[string]().
-			Title("Select a task to run:").
-			Options(options...).
-			Value(&selectedTask).
-			Run(); err != nil {
-			// Log error if task selection fails
-			x_log.Error().
-				Err(err).
-				Msg("task selection aborted")
-			fmt.Println("Error selecting task:", err)
-			return
-		}
-
-		// Log user selection
-		x_log.Info().
-			Str("task", selectedTask).
-			Msg("user selected task")
-		fmt.Printf("You selected: %s\n", selectedTask)
-
-		// Find the selected task
-		var selected *x_task.Task
-		for _, task := range tasks.Data {
-			if task.Name == selectedTask {
-				selected = task
-				break
-			}
+		// Use the selection from the command line, if any
+		selected, err := selectFromArgs(tasks, args)
+		if err != nil {
+			return withExitCode(exitConfig, err)
 		}
 
-		// Run the selected task if found
-		if selected != nil {
+		if len(selected) == 0 {
+			// Build selection options
 			x_log.Info().
-				Str("task", selected.Name).
-				Msg("executing selected task")
-
-			if err := executeTask(selected); err != nil {
-				// Log failure if task execution fails
+				Int("count", len(tasks.Data)).
+				Msg("building selection options")
+
+			options := createHuhOptions(tasks)
+
+			// Prompt user to select a task
+			x_log.Debug().Msg("prompting user to select a task")
+			var selectedTask string
+			if err := huh.NewSelect[string]().
+				Title("Select a task to run:").
+				Options(options...).
+				Value(&selectedTask).
+				Run(); err != nil {
+				// Log error if task selection fails
 				x_log.Error().
 					Err(err).
-					Str("task", selected.Name).
-					Msg("task execution failed")
-				fmt.Printf("Error executing task %s: %v\n", selected.Name, err)
-			} else {
-				// Log success if task executes correctly
-				x_log.Info().
-					Str("task", selected.Name).
-					Msg("task executed successfully")
-				fmt.Printf("Task %s executed successfully!\n", selected.Name)
+					Msg("task selection aborted")
+				return promptError(err)
 			}
-		} else {
-			// Log and notify if selected task was not found
-			x_log.Warn().
+
+			// Log user selection
+			x_log.Info().
 				Str("task", selectedTask).
-				Msg("selected task not found")
-			fmt.Println("Selected task not found.")
+				Msg("user selected task")
+			fmt.Printf("You selected: %s\n", selectedTask)
+			selected = []string{selectedTask}
 		}
+
+		if dryRunFlag {
+			return explainRun(tasks, selected, "text")
+		}
+
+		// Run the selected tasks together with their dependencies
+		x_log.Info().
+			Strs("tasks", selected).
+			Msg("executing selected tasks")
+
+		_, err = executeRun(cmd.Context(), tasks, selected)
+		return err
 	},
 }
 
 // ---------- Command Initialization ----------
 func init() {
+	runCmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "Select tasks with this tag (repeatable)")
+	runCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Print what would run, like jt explain, without executing")
+	runCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	addReportFlag(runCmd)
+	addEventsFlag(runCmd)
+	addRegressionFlag(runCmd)
+	runCmd.ValidArgsFunction = completeTasks(false)
+
 	// Register 'run' command to the root command
 	rootCmd.AddCommand(runCmd)
 }
-
-// ---------- Task Execution ----------
-func executeTask(task *x_task.Task) error {
-	// Log task execution details
-	x_log.Debug().
-		Str("task", task.Name).
-		Interface("exec", task.Exec).
-		Msg("running task command")
-
-	var cmd *exec.Cmd
-	if task.IsSudo {
-		// Use sudo if needed
-		cmd = exec.Command("sudo", task.Exec...)
-	} else {
-		cmd = exec.Command(task.Exec[0], task.Exec[1:]...)
-	}
-
-	// Capture the task output
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// Log error if task execution fails
-		x_log.Error().
-			Err(err).
-			Str("task", task.Name).
-			Bytes("output", output).
-			Msg("task execution failed")
-		return fmt.Errorf("failed to execute task: %w, Output: %s", err, string(output))
-	}
-
-	// Print task output if configured
-	if task.IsPrintOutput {
-		x_log.Debug().
-			Str("task", task.Name).
-			Int("output_len", len(output)).
-			Msg("captured task output")
-		// Log the output directly rather than using fmt.Println
-		x_log.Info().
-			Str("task", task.Name).
-			Str("output", string(output)).
-			Msg("task output")
-	}
-
-	// Log success if task completes successfully
-	x_log.Info().
-		Str("task", task.Name).
-		Msg("task completed successfully")
-	return nil
-}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/rskv-p/jtask/pkg/x_baseline"
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_queue"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_store"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Run Helpers ----------
+
+// newRunner builds a runner from the app config. The returned cancel func
+// stops the adaptive concurrency controller, if any.
+func newRunner() (*x_run.Runner, context.CancelFunc) {
+	limiter := x_queue.NewLimiter(maxConcurrent())
+
+	// Let system load drive the limit if adaptive mode is on
+	ctx, cancel := context.WithCancel(context.Background())
+	if cfg.Adaptive.Enabled {
+		x_queue.NewAdaptive(limiter, adaptiveConfig()).Start(ctx)
+	}
+
+	runner := x_run.New(cfg.RunsDir(), cfg.LockDir(), limiter)
+	runner.Retention = retention()
+	runner.Baseline = baselinePolicy()
+	return runner, cancel
+}
+
+// adaptiveConfig converts the configured load-adaptive concurrency.
+func adaptiveConfig() x_queue.AdaptiveConfig {
+	a := cfg.Adaptive
+	return x_queue.AdaptiveConfig{
+		Min:            a.Min,
+		Max:            a.Max,
+		Interval:       a.Interval,
+		LoadHigh:       a.LoadHigh,
+		LoadLow:        a.LoadLow,
+		CPUPressure:    a.CPUPressure,
+		MemoryPressure: a.MemoryPressure,
+	}
+}
+
+// retention converts the configured retention for the run store. Negative
+// values disable a limit.
+func retention() x_store.Retention {
+	var r x_store.Retention
+	if cfg.Retention.MaxAgeDays > 0 {
+		r.MaxAge = time.Duration(cfg.Retention.MaxAgeDays) * 24 * time.Hour
+	}
+	if cfg.Retention.MaxRuns > 0 {
+		r.MaxRuns = cfg.Retention.MaxRuns
+	}
+	return r
+}
+
+// baselinePolicy converts the configured regression detection. Negative
+// values disable baselines or a check.
+func baselinePolicy() x_baseline.Policy {
+	r := cfg.Regression
+	if r.Window <= 0 {
+		return x_baseline.Policy{}
+	}
+	p := x_baseline.Policy{
+		Window:     r.Window,
+		MinSamples: r.MinSamples,
+		MinDelta:   time.Duration(r.MinDeltaMS) * time.Millisecond,
+	}
+	if r.Factor > 0 {
+		p.Factor = r.Factor
+	}
+	if r.StdDevs > 0 {
+		p.StdDevs = r.StdDevs
+	}
+	return p
+}
+
+// addRegressionFlag registers --fail-on-regression on a command that runs tasks.
+func addRegressionFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&failOnRegressionFlag, "fail-on-regression", false,
+		"Exit with code 4 if a task ran slower than its duration baseline")
+}
+
+// maxConcurrent returns the max number of concurrent tasks from the config,
+// unless overridden by -j.
+func maxConcurrent() int {
+	n := cfg.MaxConcurrent
+	if jobsFlag > 0 {
+		n = jobsFlag
+	}
+	if n <= 0 {
+		n = 5 // Set a default if not specified
+	}
+	return n
+}
+
+// withCollectionLock holds the collection lock while fn runs.
+// It returns skipped=true without calling fn if the lock is held elsewhere
+// and the collection's policy is "skip".
+func withCollectionLock(tasks *x_task.TaskCollection, fn func() error) (skipped bool, err error) {
+	lock, skip, err := acquireLock(x_lock.CollectionLockName(tasks.Name), tasks.Concurrency)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock task collection: %w", err)
+	}
+	if skip {
+		fmt.Fprintf(summaryOut, "Task collection %s is already running, skipping.\n", tasks.Name)
+		return true, nil
+	}
+	defer lock.Release()
+
+	return false, fn()
+}
+
+// resolveError attaches the exit code to an error of Resolve: an unknown
+// task name is a bad selection, an unknown dependency or a cycle an invalid
+// tasks file.
+func resolveError(err error) error {
+	if errors.Is(err, x_task.ErrTaskNotFound) {
+		return withExitCode(exitConfig, err)
+	}
+	return withExitCode(exitInvalid, err)
+}
+
+// executeRun runs the named tasks and their dependencies as a new run.
+// Ctrl+C or SIGTERM cancels the run. The returned error carries the exit
+// code of the command: failed tasks, cancellation or a bad selection.
+func executeRun(ctx context.Context, tasks *x_task.TaskCollection, names []string) (*x_run.State, error) {
+	selected, err := tasks.Resolve(names)
+	if err != nil {
+		return nil, resolveError(err)
+	}
+
+	events, closeEvents, err := openEvents()
+	if err != nil {
+		return nil, withExitCode(exitConfig, err)
+	}
+	defer closeEvents()
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var state *x_run.State
+	skipped, err := withCollectionLock(tasks, func() error {
+		runner, cancel := newRunner()
+		defer cancel()
+		runner.Events = events
+
+		// Record an absolute tasks file path so the run can be resumed from anywhere
+		path, err := filepath.Abs(pathFlag)
+		if err != nil {
+			path = pathFlag
+		}
+
+		state, err = runner.Start(ctx, tasks, path, selected)
+		if state != nil {
+			reportRun(state)
+		}
+		return err
+	})
+	if err != nil {
+		return state, withExitCode(exitFailed, err)
+	}
+	if skipped {
+		return nil, nil
+	}
+	return state, withReports(state, runOutcome(ctx, state))
+}
+
+// runOutcome returns the error a command reports for a finished run, or nil
+// if every task succeeded or was skipped because its lock was held, and
+// with --fail-on-regression none ran slower than its baseline.
+func runOutcome(ctx context.Context, state *x_run.State) error {
+	if ctx.Err() != nil {
+		return withExitCode(exitCancelled, fmt.Errorf("run %s was cancelled", state.ID))
+	}
+	if state.Status == x_run.StatusSuccess {
+		if slow := state.Regressions(); failOnRegressionFlag && len(slow) > 0 {
+			return withExitCode(exitRegression, fmt.Errorf("run %s: %d task(s) slower than their duration baseline", state.ID, len(slow)))
+		}
+		return nil
+	}
+	failed := 0
+	for _, ts := range state.Tasks {
+		if ts.Status != x_run.StatusSuccess && ts.Error != "" {
+			failed++
+		}
+	}
+	return withExitCode(exitFailed, fmt.Errorf("run %s failed: %d of %d task(s) did not succeed", state.ID, failed, len(state.Tasks)))
+}
+
+// reportRun prints a summary table of a run.
+func reportRun(state *x_run.State) {
+	fmt.Fprintln(summaryOut, renderRunSummary(state))
+
+	if state.Status != x_run.StatusSuccess {
+		x_log.Warn().
+			Str("run", state.ID).
+			Str("status", string(state.Status)).
+			Msg("run did not complete")
+		fmt.Fprintf(summaryOut, "Run %s did not complete. Resume it with: jt resume %s\n", state.ID, state.ID)
+	}
+}
+
+// regressionColor marks durations slower than the baseline in the summary.
+var regressionColor = lipgloss.Color("3")
+
+// statusColors are the terminal colors of task statuses in the summary.
+var statusColors = map[x_run.Status]lipgloss.Color{
+	x_run.StatusSuccess: "2",
+	x_run.StatusFailed:  "1",
+	x_run.StatusSkipped: "3",
+}
+
+// renderRunSummary renders the status, duration, exit code and attempts of
+// every task in a run as a table, with durations slower than the baseline
+// highlighted.
+func renderRunSummary(state *x_run.State) string {
+	header := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cell := lipgloss.NewStyle().Padding(0, 1)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		Headers("TASK", "STATUS", "DURATION", "EXIT CODE", "ATTEMPTS").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return header
+			case col == 1:
+				return cell.Foreground(statusColors[state.Tasks[row].Status])
+			case col == 2 && state.Tasks[row].Regression != nil:
+				return cell.Foreground(regressionColor)
+			default:
+				return cell
+			}
+		})
+
+	counts := make(map[x_run.Status]int)
+	for _, ts := range state.Tasks {
+		counts[ts.Status]++
+
+		duration, code, attempts := "-", "-", "-"
+		if d := ts.Duration(); d > 0 {
+			duration = d.Round(time.Millisecond).String()
+		}
+		if ts.Regression != nil {
+			duration += " ▲ " + ts.Regression.String()
+		}
+		if ts.Status == x_run.StatusSuccess || ts.Status == x_run.StatusFailed && ts.ExitCode >= 0 {
+			code = fmt.Sprint(ts.ExitCode)
+		}
+		if ts.Attempts > 0 {
+			attempts = fmt.Sprint(ts.Attempts)
+		}
+		t.Row(ts.Name, string(ts.Status), duration, code, attempts)
+	}
+
+	footer := fmt.Sprintf("Run %s: %d succeeded, %d failed, %d skipped",
+		state.ID, counts[x_run.StatusSuccess], counts[x_run.StatusFailed], counts[x_run.StatusSkipped])
+	if slow := state.Regressions(); len(slow) > 0 {
+		footer += fmt.Sprintf(", %d slower than baseline", len(slow))
+	}
+	return t.Render() + "\n" + footer
+}
@@ -1,19 +1,25 @@
 package cmd
 
 import (
-	"sync"
+	"fmt"
+	"os"
 
 	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
 	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_select"
 	"github.com/rskv-p/jtask/pkg/x_task"
 	"github.com/spf13/cobra"
 )
 
 var runsCmd = &cobra.Command{
-	Use:   "runs",
+	Use:   "runs [task|selector]...",
 	Short: "Select multiple tasks to run in parallel",
-	Long:  "Select multiple tasks from the list and execute them in parallel.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: "Execute tasks in parallel. Tasks are given like for run, by name, glob, --tag or selector expression; " +
+		"without a selection, prompt for tasks when stdin is a terminal.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
 		// Log the beginning of task loading
 		x_log.Info().
 			Str("path", pathFlag).
@@ -27,37 +33,45 @@ var runsCmd = &cobra.Command{
 				Err(err).
 				Str("path", pathFlag).
 				Msg("failed to load tasks")
-			return
+			return withExitCode(exitConfig, err)
 		}
 
 		// If no tasks are available, log and return
 		if len(tasks.Data) == 0 {
 			x_log.Info().Msg("no tasks available to select")
-			return
+			return nil
 		}
 
-		// Log the task count and start building selection options
-		x_log.Info().
-			Int("count", len(tasks.Data)).
-			Msg("building task selection options")
-
-		options := createHuhOptions(tasks)
-
-		// Variable to store selected tasks
-		var selectedTasks []string
-
-		// Prompt the user to select multiple tasks
-		x_log.Debug().Msg("prompting user to select multiple tasks")
-		if err := huh.NewMultiSelect[string]().
-			Title("Select tasks to run in parallel:").
-			Options(options...).
-			Value(&selectedTasks).
-			Run(); err != nil {
-			// Log error if task selection fails
+		// Use the selection from the command line, if any
+		selectedTasks, err := selectFromArgs(tasks, args)
+		if err != nil {
 			x_log.Error().
 				Err(err).
 				Msg("task selection failed")
-			return
+			return withExitCode(exitConfig, err)
+		}
+
+		if len(selectedTasks) == 0 {
+			// Log the task count and start building selection options
+			x_log.Info().
+				Int("count", len(tasks.Data)).
+				Msg("building task selection options")
+
+			options := createHuhOptions(tasks)
+
+			// Prompt the user to select multiple tasks
+			x_log.Debug().Msg("prompting user to select multiple tasks")
+			if err := huh.NewMultiSelect[string]().
+				Title("Select tasks to run in parallel:").
+				Options(options...).
+				Value(&selectedTasks).
+				Run(); err != nil {
+				// Log error if task selection fails
+				x_log.Error().
+					Err(err).
+					Msg("task selection failed")
+				return promptError(err)
+			}
 		}
 
 		// Log selected tasks count and names
@@ -71,79 +85,78 @@ var runsCmd = &cobra.Command{
 			Strs("selected_tasks", selectedTasks).
 			Msg("the following tasks were selected")
 
-		// ---------- Parallel Task Execution ----------
-		// Get max concurrent tasks from config
-		maxConcurrent := cfg.MaxConcurrent
-		if maxConcurrent <= 0 {
-			maxConcurrent = 5 // Set a default if not specified
+		if dryRunFlag {
+			return explainRun(tasks, selectedTasks, "text")
 		}
-		sem := make(chan struct{}, maxConcurrent) // Semaphore to limit concurrency
-
-		// WaitGroup to ensure all tasks are processed
-		var wg sync.WaitGroup
-		for _, name := range selectedTasks {
-			wg.Add(1)
-			go func(taskName string) {
-				defer wg.Done()
-
-				// Use semaphore to limit concurrency
-				sem <- struct{}{}
-				defer func() { <-sem }()
-
-				// Log task start
-				x_log.Info().
-					Str("task", taskName).
-					Msg("starting task")
-
-				// Find the task by name
-				if task := findTaskByName(tasks, taskName); task != nil {
-					// Execute the task
-					if err := executeTask(task); err != nil {
-						// Log task execution failure
-						x_log.Error().
-							Str("task", task.Name).
-							Err(err).
-							Msg("task execution failed")
-					}
-				} else {
-					// Log if the task is not found
-					x_log.Warn().
-						Str("task", taskName).
-						Msg("task not found")
-				}
-			}(name)
+
+		// ---------- Parallel Task Execution ----------
+		if _, err := executeRun(cmd.Context(), tasks, selectedTasks); err != nil {
+			x_log.Error().
+				Err(err).
+				Msg("run failed")
+			return err
 		}
-		wg.Wait() // Wait for all tasks to be processed
 
 		// Log after all tasks are processed
 		x_log.Info().
 			Int("done", len(selectedTasks)).
 			Msg("all selected tasks processed")
+		return nil
 	},
 }
 
 // ---------- Command Initialization ----------
 func init() {
+	runsCmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "Select tasks with this tag (repeatable)")
+	runsCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Print what would run, like jt explain, without executing")
+	runsCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	addReportFlag(runsCmd)
+	addEventsFlag(runsCmd)
+	addRegressionFlag(runsCmd)
+	runsCmd.ValidArgsFunction = completeTasks(false)
 	rootCmd.AddCommand(runsCmd) // Register the 'runs' command
 }
 
 // ---------- Helper Functions ----------
 
-// createHuhOptions builds selection options from tasks.
-func createHuhOptions(tasks *x_task.TaskCollection) []huh.Option[string] {
-	var options []huh.Option[string]
-	for _, task := range tasks.Data {
-		options = append(options, huh.NewOption(task.Name, task.Name)) // Add task options for selection
+// selectFromArgs applies the command line selection. It returns no names when
+// nothing was selected and a prompt may be shown, and an error when a prompt
+// is needed but stdin is not a terminal.
+func selectFromArgs(tasks *x_task.TaskCollection, args []string) ([]string, error) {
+	for _, arg := range args {
+		if t := tasks.Find(arg); t != nil && t.Internal {
+			return nil, fmt.Errorf("task %q is internal and runs only as a dependency", arg)
+		}
 	}
-	return options
+	selected, err := x_select.Select(tasks, args, tagFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Internal tasks matched by globs or tags are left to their dependents
+	var names []string
+	for _, name := range selected {
+		if !tasks.Find(name).Internal {
+			names = append(names, name)
+		}
+	}
+	if len(selected) > 0 && len(names) == 0 {
+		return nil, fmt.Errorf("only internal tasks match the selection")
+	}
+	if len(names) == 0 && !isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil, fmt.Errorf("no tasks selected and stdin is not a terminal: pass task names, selectors or --tag")
+	}
+	return names, nil
 }
 
-// findTaskByName returns a task by its name.
-func findTaskByName(tasks *x_task.TaskCollection, name string) *x_task.Task {
+// createHuhOptions builds selection options from tasks, leaving out hidden and internal ones.
+func createHuhOptions(tasks *x_task.TaskCollection) []huh.Option[string] {
+	var options []huh.Option[string]
 	for _, task := range tasks.Data {
-		if task.Name == name {
-			return task // Return the task if found by name
+		if task.Hidden || task.Internal {
+			continue
 		}
+		options = append(options, huh.NewOption(task.Name, task.Name)) // Add task options for selection
 	}
-	return nil // Return nil if task not found
+	return options
 }
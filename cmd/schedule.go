@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_cron"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/spf13/cobra"
+)
+
+// nextCount is the number of upcoming fire times shown per task.
+var nextCount int
+
+//
+// ---------- Command Definition ----------
+
+// scheduleCmd groups schedule related commands.
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Inspect scheduled tasks",
+}
+
+// scheduleLsCmd lists scheduled tasks with their next fire times.
+var scheduleLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List scheduled tasks and their next fire times",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return err
+		}
+
+		entries, err := x_cron.Entries(tasks)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No scheduled tasks found.")
+			return nil
+		}
+
+		last, err := x_cron.LoadLastFires(cfg.ScheduleStatePath())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TASK\tSCHEDULE\tOVERLAP\tLAST\tNEXT")
+		now := time.Now()
+		for _, e := range entries {
+			lastStr := "-"
+			if t, ok := last[e.Task.Name]; ok {
+				lastStr = t.Format(time.RFC3339)
+			}
+
+			var next []string
+			t := now
+			for i := 0; i < nextCount; i++ {
+				if t = e.Schedule.Next(t); t.IsZero() {
+					break
+				}
+				next = append(next, t.Format(time.RFC3339))
+			}
+			if len(next) == 0 {
+				next = append(next, "never")
+			}
+
+			spec := e.Task.Schedule
+			if e.Task.Timezone != "" {
+				spec += " (" + e.Task.Timezone + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Task.Name, spec, e.Overlap, lastStr, strings.Join(next, ", "))
+		}
+		return w.Flush()
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	scheduleLsCmd.Flags().IntVarP(&nextCount, "next", "n", 1, "Number of upcoming fire times to show")
+	scheduleCmd.AddCommand(scheduleLsCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_stats"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	statsTask   string // Only tasks matching this glob
+	statsSince  string // Only executions since this time
+	statsTrend  int    // Executions shown in the trend
+	statsFormat string // Output format
+)
+
+//
+// ---------- Command Definition ----------
+
+// statsCmd computes duration percentiles, failure and flake rates per task.
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show duration, failure and flake statistics of tasks",
+	Long: "Compute per-task statistics from the run history: p50/p90/p99 durations of passing executions, " +
+		"failure rate, flake rate (failures followed by a pass of the same task definition on resume or the next run) " +
+		"and a trend of the most recent durations, with failures in red.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		opts := x_stats.Options{Task: statsTask, Trend: statsTrend}
+		if statsSince != "" {
+			since, err := x_run.ParseSince(statsSince, time.Now())
+			if err != nil {
+				return err
+			}
+			opts.Since = since
+		}
+
+		states, err := x_run.Find(cfg.RunsDir(), x_run.Query{Task: statsTask})
+		if err != nil {
+			return err
+		}
+		results, err := x_run.AllResults(cfg.RunsDir())
+		if err != nil {
+			return err
+		}
+
+		stats := x_stats.Compute(x_stats.Executions(states, results), opts)
+		if len(stats) == 0 && (statsFormat == "" || statsFormat == "table") {
+			fmt.Println("No task executions found.")
+			return nil
+		}
+		return x_stats.Render(os.Stdout, stats, statsFormat)
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	statsCmd.Flags().StringVar(&statsTask, "task", "", "Only tasks matching this name or glob")
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "Only executions since a duration ago (24h, 7d) or a date")
+	statsCmd.Flags().IntVar(&statsTrend, "trend", 20, "Number of recent executions in the trend")
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "table", "Output format: table, csv or json")
+	statsCmd.RegisterFlagCompletionFunc("task", completeTasks(true))
+	statsCmd.RegisterFlagCompletionFunc("format", completeFormats(x_stats.Formats))
+
+	rootCmd.AddCommand(statsCmd)
+}
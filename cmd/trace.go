@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rskv-p/jtask/pkg/x_report"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var traceOut string // File the trace is written to, stdout if empty
+
+//
+// ---------- Command Definition ----------
+
+// traceCmd exports a run from history as a Chrome trace.
+var traceCmd = &cobra.Command{
+	Use:   "trace [run-id]",
+	Short: "Export a run as a Chrome trace",
+	Long: "Write a run from history in Chrome Trace Event format, which opens in chrome://tracing and ui.perfetto.dev: " +
+		"one track per concurrency slot with a span per task attempt, nested spans for lock waits and retries, " +
+		"and the waits for a free slot. Without a run ID, export the latest run.",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRuns,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		state, err := loadRun(args)
+		if err != nil {
+			return err
+		}
+		report, err := x_report.Load(cfg.RunsDir(), state)
+		if err != nil {
+			return fmt.Errorf("failed to load results of run %s: %w", state.ID, err)
+		}
+
+		if traceOut == "" {
+			return report.Render(os.Stdout, "trace")
+		}
+		if err := report.Write(x_report.Spec{Format: "trace", Path: traceOut}); err != nil {
+			return err
+		}
+		fmt.Printf("Trace of run %s written to %s\n", state.ID, traceOut)
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	traceCmd.Flags().StringVarP(&traceOut, "output", "o", "", "Write the trace to a file instead of stdout")
+
+	rootCmd.AddCommand(traceCmd)
+}
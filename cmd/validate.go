@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rskv-p/jtask/pkg/x_validate"
+	"github.com/spf13/cobra"
+)
+
+// validateFormat is the output format of validate.
+var validateFormat string
+
+//
+// ---------- Command Definition ----------
+
+// validateCmd statically checks a tasks file.
+var validateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a tasks file for problems",
+	Long: "Check a tasks file for syntax errors, unknown fields, duplicate task names, empty commands, " +
+		"commands missing from PATH, unknown dependencies, dependency cycles and invalid settings. " +
+		"Every problem is reported with file:line:column. Exits non-zero if there are errors.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := pathFlag
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		diags, err := x_validate.Validate(path)
+		if err != nil {
+			return err
+		}
+		if err := x_validate.Write(os.Stdout, validateFormat, diags); err != nil {
+			return err
+		}
+
+		if x_validate.HasErrors(diags) {
+			// The diagnostics were printed already
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return withExitCode(exitInvalid, fmt.Errorf("%s is invalid", path))
+		}
+		return nil
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "f", "text", "Output format: text, json or sarif")
+	validateCmd.RegisterFlagCompletionFunc("format", completeFormats(x_validate.Formats))
+	validateCmd.ValidArgsFunction = cobra.FixedCompletions([]string{"json"}, cobra.ShellCompDirectiveFilterFileExt)
+	rootCmd.AddCommand(validateCmd)
+}
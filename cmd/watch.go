@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_cron"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/rskv-p/jtask/pkg/x_watch"
+	"github.com/spf13/cobra"
+)
+
+// ---------- Flags ----------
+var (
+	watchOnChange string        // "queue" or "kill"
+	watchDebounce time.Duration // quiet period before re-running
+	watchPoll     bool          // force polling instead of inotify
+)
+
+//
+// ---------- Command Definition ----------
+
+// watchCmd re-runs tasks when files matching their watch globs change.
+var watchCmd = &cobra.Command{
+	Use:   "watch <task>...",
+	Short: "Re-run tasks when their files change",
+	Long: "Run the given tasks, then run them again whenever files matching their `watch` globs change. " +
+		"Tasks depending on a re-run task are re-run too. Files ignored by .gitignore or `watch_ignore` are not watched.",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if watchOnChange != "queue" && watchOnChange != "kill" {
+			return fmt.Errorf("invalid --on-change %q: use queue or kill", watchOnChange)
+		}
+
+		tasks, err := x_task.LoadTasks(pathFlag)
+		if err != nil {
+			return err
+		}
+
+		// Collect patterns and ignore rules of the watched tasks
+		ignore := x_watch.NewIgnore(".git/")
+		if err := ignore.AddFile(".gitignore"); err != nil {
+			return err
+		}
+		var patterns []string
+		for _, name := range args {
+			t := tasks.Find(name)
+			if t == nil {
+				return fmt.Errorf("task %q not found", name)
+			}
+			if len(t.Watch) == 0 {
+				return fmt.Errorf("task %q has no watch globs", name)
+			}
+			patterns = append(patterns, t.Watch...)
+			ignore.Add(t.WatchIgnore...)
+		}
+
+		watcher, err := x_watch.New(x_watch.Options{
+			Root:     ".",
+			Patterns: patterns,
+			Ignore:   ignore,
+			Debounce: watchDebounce,
+			Poll:     watchPoll,
+		})
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+
+		// Stop gracefully on Ctrl+C or SIGTERM
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		path, err := filepath.Abs(pathFlag)
+		if err != nil {
+			path = pathFlag
+		}
+		overlap := x_cron.OverlapQueue
+		if watchOnChange == "kill" {
+			overlap = x_cron.OverlapReplace
+		}
+		runs := &x_cron.Serial[[]*x_task.Task]{
+			Name:    "watch",
+			Overlap: overlap,
+			Run: func(ctx context.Context, selected []*x_task.Task) {
+				if err := runWatched(ctx, tasks, path, selected); err != nil {
+					x_log.Error().Err(err).Msg("watch run failed")
+				}
+			},
+			Merge: func(queued, selected []*x_task.Task) []*x_task.Task {
+				return mergeTasks(tasks, queued, selected)
+			},
+		}
+
+		// Initial run includes the dependencies of the watched tasks
+		initial, err := tasks.Resolve(args)
+		if err != nil {
+			return err
+		}
+		runs.Trigger(ctx, initial)
+
+		fmt.Printf("Watching %d pattern(s). Press Ctrl+C to stop.\n", len(patterns))
+		for {
+			select {
+			case <-ctx.Done():
+				runs.Wait()
+				return nil
+			case files, ok := <-watcher.Changes:
+				if !ok {
+					runs.Wait()
+					return nil
+				}
+				changed := changedTasks(tasks, args, files)
+				if len(changed) == 0 {
+					continue
+				}
+
+				x_log.Info().
+					Strs("files", files).
+					Strs("tasks", changed).
+					Msg("files changed, re-running tasks")
+
+				selected, err := tasks.WithDependents(changed)
+				if err != nil {
+					return err
+				}
+				runs.Trigger(ctx, selected)
+			}
+		}
+	},
+}
+
+// ---------- Command Initialization ----------
+func init() {
+	watchCmd.Flags().StringVar(&watchOnChange, "on-change", "queue", "What to do with an in-flight run on change: queue or kill")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 300*time.Millisecond, "Quiet period before re-running")
+	watchCmd.Flags().BoolVar(&watchPoll, "poll", false, "Poll for changes instead of using inotify")
+	watchCmd.RegisterFlagCompletionFunc("on-change", cobra.FixedCompletions([]string{"queue", "kill"}, cobra.ShellCompDirectiveNoFileComp))
+	watchCmd.ValidArgsFunction = completeTasks(false)
+	rootCmd.AddCommand(watchCmd)
+}
+
+// ---------- Helper Functions ----------
+
+// changedTasks returns the watched tasks with a glob matching any changed file.
+func changedTasks(tasks *x_task.TaskCollection, names, files []string) []string {
+	var changed []string
+	for _, name := range names {
+		t := tasks.Find(name)
+	match:
+		for _, pattern := range t.Watch {
+			for _, f := range files {
+				if x_watch.Match(pattern, f) {
+					changed = append(changed, name)
+					break match
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// runWatched runs selected under the collection lock and prints its summary.
+func runWatched(ctx context.Context, tasks *x_task.TaskCollection, path string, selected []*x_task.Task) error {
+	_, err := withCollectionLock(tasks, func() error {
+		runner, stopAdaptive := newRunner()
+		defer stopAdaptive()
+
+		state, err := runner.Start(ctx, tasks, path, selected)
+		if state != nil {
+			reportRun(state)
+		}
+		return err
+	})
+	return err
+}
+
+// mergeTasks returns the union of two task lists in dependency order.
+func mergeTasks(c *x_task.TaskCollection, a, b []*x_task.Task) []*x_task.Task {
+	var names []string
+	for _, t := range append(append([]*x_task.Task{}, a...), b...) {
+		names = append(names, t.Name)
+	}
+	merged, err := c.WithDependents(names)
+	if err != nil {
+		return b
+	}
+	return merged
+}
@@ -0,0 +1,228 @@
+package x_artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_watch"
+)
+
+// Artifacts are files a task leaves behind, such as binaries or coverage
+// reports, selected by globs relative to the working directory. They are
+// copied into the artifact directory of the task, keeping their relative
+// paths, so that a task rewriting a file in place does not change the
+// artifacts of earlier runs. Where the file system supports it the copy is
+// a copy-on-write clone, which costs no space until either file changes.
+
+//
+// ---------- Data Structures ----------
+
+// File is one collected artifact, as listed in the manifest of a task.
+type File struct {
+	Path   string `json:"path"`   // Slash-separated path relative to the artifact directory
+	Size   int64  `json:"size"`   // Size in bytes
+	SHA256 string `json:"sha256"` // Hex encoded SHA-256 of the content
+}
+
+//
+// ---------- Public Functions ----------
+
+// Collect copies the files under workDir matching any of patterns
+// into destDir, which is replaced, and returns their manifest ordered by
+// path. Patterns use the syntax of x_watch.Match; a pattern matching no
+// file is not an error. The directories in exclude, such as the runs
+// directory holding earlier artifacts, and .git are never searched.
+func Collect(patterns []string, workDir, destDir string, exclude ...string) ([]File, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("invalid artifact glob %q: %w", p, err)
+		}
+	}
+
+	matches, err := match(patterns, workDir, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	// Artifacts of an earlier attempt are replaced
+	if err := os.RemoveAll(destDir); err != nil {
+		return nil, fmt.Errorf("failed to clear artifact dir: %w", err)
+	}
+
+	var files []File
+	for _, rel := range matches {
+		dst := filepath.Join(destDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return files, fmt.Errorf("failed to create artifact dir: %w", err)
+		}
+		f, err := copyFile(filepath.Join(workDir, filepath.FromSlash(rel)), dst)
+		if err != nil {
+			return files, fmt.Errorf("failed to collect artifact %s: %w", rel, err)
+		}
+		f.Path = rel
+		files = append(files, f)
+	}
+
+	x_log.Debug().
+		Int("files", len(files)).
+		Str("dir", destDir).
+		Msg("collected artifacts")
+	return files, nil
+}
+
+// Copy copies the artifact f from the artifact directory srcDir to the
+// same relative path under destDir. It fails, leaving no file behind, if
+// the content does not match the size and checksum of the manifest.
+func Copy(f File, srcDir, destDir string) error {
+	dst := filepath.Join(destDir, filepath.FromSlash(f.Path))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create dir: %w", err)
+	}
+	got, err := copyFile(filepath.Join(srcDir, filepath.FromSlash(f.Path)), dst)
+	if err != nil {
+		return fmt.Errorf("failed to copy artifact %s: %w", f.Path, err)
+	}
+	if got.Size != f.Size || got.SHA256 != f.SHA256 {
+		os.Remove(dst)
+		return fmt.Errorf("artifact %s does not match its manifest", f.Path)
+	}
+	return nil
+}
+
+// FormatSize formats a size in bytes with a binary unit, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// DirName returns the name of the artifact directory of a task, with path
+// separators replaced so that every task gets one directory.
+func DirName(task string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(task)
+	if name == "" || name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
+
+//
+// ---------- Helper Functions ----------
+
+// match returns the regular files under workDir matching any of patterns,
+// as sorted slash-separated relative paths.
+func match(patterns []string, workDir string, exclude []string) ([]string, error) {
+	skip := make(map[string]bool)
+	for _, dir := range append(exclude, filepath.Join(workDir, ".git")) {
+		if abs, err := filepath.Abs(dir); err == nil {
+			skip[abs] = true
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, pattern := range patterns {
+		root := filepath.Join(workDir, filepath.FromSlash(globRoot(pattern)))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if abs, err := filepath.Abs(p); err == nil && skip[abs] {
+					return filepath.SkipDir
+				}
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(workDir, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if x_watch.Match(pattern, rel) {
+				found[rel] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to match artifacts %q: %w", pattern, err)
+		}
+	}
+
+	matches := make([]string, 0, len(found))
+	for rel := range found {
+		matches = append(matches, rel)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// globRoot returns the directory part of a pattern before the first wildcard.
+func globRoot(pattern string) string {
+	parts := strings.Split(strings.Trim(path.Clean(filepath.ToSlash(pattern)), "/"), "/")
+	var root []string
+	for _, part := range parts[:len(parts)-1] {
+		if strings.ContainsAny(part, "*?[") {
+			break
+		}
+		root = append(root, part)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	return strings.Join(root, "/")
+}
+
+// copyFile copies src to dst, keeping its permissions, and returns the
+// size and checksum of the copied content. It clones the file where the
+// file system supports it.
+func copyFile(src, dst string) (File, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return File{}, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return File{}, err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return File{}, err
+	}
+
+	h := sha256.New()
+	var size int64
+	if clone(in, out) == nil {
+		size, err = io.Copy(h, in)
+	} else {
+		size, err = io.Copy(io.MultiWriter(out, h), in)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return File{}, err
+	}
+	return File{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
@@ -0,0 +1,111 @@
+package x_artifact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//
+// ---------- Helpers ----------
+
+// writeFile creates a file with content below dir.
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestCollect verifies matching, the manifest and excluded directories.
+func TestCollect(t *testing.T) {
+	work := t.TempDir()
+	writeFile(t, work, "dist/app", "binary")
+	writeFile(t, work, "dist/sub/app.map", "map")
+	writeFile(t, work, "coverage.out", "mode: set")
+	writeFile(t, work, "main.go", "package main")
+	writeFile(t, work, "space/runs/old/artifacts/build/coverage.out", "old")
+
+	dest := filepath.Join(work, "space", "runs", "new", "artifacts", "build")
+	writeFile(t, dest, "stale", "from an earlier attempt")
+
+	files, err := Collect([]string{"dist/**", "**/*.out", "missing/*"}, work, dest, filepath.Join(work, "space", "runs"))
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+
+	want := []string{"coverage.out", "dist/app", "dist/sub/app.map"}
+	if len(files) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, files)
+	}
+	for i, f := range files {
+		if f.Path != want[i] {
+			t.Errorf("file %d: expected %s, got %s", i, want[i], f.Path)
+		}
+	}
+	if files[1].Size != 6 || files[1].SHA256 != "9a3a45d01531a20e89ac6ae10b0b0beb0492acd7216a368aa062d1a5fecaf9cd" {
+		t.Errorf("unexpected manifest entry: %+v", files[1])
+	}
+	if _, err := os.Stat(filepath.Join(dest, "stale")); !os.IsNotExist(err) {
+		t.Errorf("expected artifacts of an earlier attempt to be removed")
+	}
+
+	if _, err := Collect([]string{"dist/[a"}, work, dest); err == nil {
+		t.Errorf("expected an error for an invalid glob")
+	}
+}
+
+// TestCopy verifies that copies are checked against the manifest.
+func TestCopy(t *testing.T) {
+	work, dest, out := t.TempDir(), t.TempDir(), t.TempDir()
+	writeFile(t, work, "dist/app", "binary")
+
+	files, err := Collect([]string{"dist/*"}, work, dest)
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	if err := Copy(files[0], dest, out); err != nil {
+		t.Fatalf("Copy returned error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(out, "dist", "app")); err != nil || string(data) != "binary" {
+		t.Errorf("unexpected copy: %q, %v", data, err)
+	}
+
+	// Rewriting the workspace file in place keeps the artifact
+	writeFile(t, work, "dist/app", "changed")
+	if err := Copy(files[0], dest, out); err != nil {
+		t.Errorf("expected the artifact to keep its content, got %v", err)
+	}
+
+	writeFile(t, dest, "dist/app", "corrupt")
+	if err := Copy(files[0], dest, out); err == nil {
+		t.Errorf("expected a manifest mismatch")
+	}
+}
+
+// TestDirName verifies that task names map to one directory.
+func TestDirName(t *testing.T) {
+	cases := map[string]string{"build": "build", "ci/test": "ci_test", "..": "_..", "": "_"}
+	for task, want := range cases {
+		if got := DirName(task); got != want {
+			t.Errorf("DirName(%q) = %q, want %q", task, got, want)
+		}
+	}
+}
+
+// TestFormatSize verifies binary size units.
+func TestFormatSize(t *testing.T) {
+	cases := map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB"}
+	for n, want := range cases {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package x_artifact
+
+import (
+	"errors"
+	"os"
+)
+
+// clone is only available on Linux; other systems copy the content.
+func clone(src, dst *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,112 @@
+package x_baseline
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// A baseline is the mean and standard deviation of the durations of the
+// most recent passing executions of a task. Every run records the baseline
+// of the tasks it ran in the run store, rolled forward by their durations,
+// so the next run finds it in the newest run that executed the task.
+// Failures are left out: they often end early and would hide a slow down.
+
+//
+// ---------- Data Structures ----------
+
+// Baseline is the rolling duration baseline of a task.
+type Baseline struct {
+	SamplesMS []int64 `json:"samples_ms"` // Durations of the most recent passing executions, oldest first
+	MeanMS    float64 `json:"mean_ms"`    // Mean of the samples
+	StdDevMS  float64 `json:"stddev_ms"`  // Population standard deviation of the samples
+}
+
+// Policy decides when a duration is a regression. A duration is flagged
+// when it exceeds the mean by Factor or by StdDevs standard deviations,
+// and by at least MinDelta. Zero Factor or StdDevs disable that check.
+type Policy struct {
+	Window     int           // Number of executions in the baseline
+	MinSamples int           // Executions needed before regressions are flagged
+	Factor     float64       // Flag durations longer than Factor times the mean, e.g. 1.5
+	StdDevs    float64       // Flag durations more than this many standard deviations above the mean
+	MinDelta   time.Duration // Ignore slow downs smaller than this
+}
+
+// Regression describes a task that ran slower than its baseline.
+type Regression struct {
+	DurationMS int64   `json:"duration_ms"` // Duration of the execution
+	MeanMS     float64 `json:"mean_ms"`     // Mean of the baseline
+	StdDevMS   float64 `json:"stddev_ms"`   // Standard deviation of the baseline
+	Samples    int     `json:"samples"`     // Executions in the baseline
+	Ratio      float64 `json:"ratio"`       // Duration divided by the mean
+	Sigma      float64 `json:"sigma"`       // Standard deviations above the mean, 0 if the baseline has none
+}
+
+//
+// ---------- Public Functions ----------
+
+// Enabled reports whether the policy tracks baselines at all.
+func (p Policy) Enabled() bool {
+	return p.Window > 0
+}
+
+// Add returns the baseline rolled forward by a passing execution of d,
+// keeping the most recent window samples.
+func (b Baseline) Add(d time.Duration, window int) Baseline {
+	samples := append(append([]int64(nil), b.SamplesMS...), d.Milliseconds())
+	if window > 0 && len(samples) > window {
+		samples = samples[len(samples)-window:]
+	}
+
+	next := Baseline{SamplesMS: samples}
+	for _, s := range samples {
+		next.MeanMS += float64(s)
+	}
+	next.MeanMS /= float64(len(samples))
+	for _, s := range samples {
+		next.StdDevMS += (float64(s) - next.MeanMS) * (float64(s) - next.MeanMS)
+	}
+	next.StdDevMS = math.Sqrt(next.StdDevMS / float64(len(samples)))
+	return next
+}
+
+// Check returns the regression of an execution of d against b, or nil if
+// d is within the policy or b has too few samples to judge.
+func (p Policy) Check(b Baseline, d time.Duration) *Regression {
+	if !p.Enabled() || len(b.SamplesMS) == 0 || len(b.SamplesMS) < p.MinSamples || b.MeanMS <= 0 {
+		return nil
+	}
+
+	ms := float64(d.Milliseconds())
+	if ms-b.MeanMS < float64(p.MinDelta.Milliseconds()) {
+		return nil
+	}
+	r := &Regression{
+		DurationMS: d.Milliseconds(),
+		MeanMS:     b.MeanMS,
+		StdDevMS:   b.StdDevMS,
+		Samples:    len(b.SamplesMS),
+		Ratio:      ms / b.MeanMS,
+	}
+	if b.StdDevMS > 0 {
+		r.Sigma = (ms - b.MeanMS) / b.StdDevMS
+	}
+
+	byFactor := p.Factor > 0 && r.Ratio > p.Factor
+	byStdDevs := p.StdDevs > 0 && b.StdDevMS > 0 && r.Sigma > p.StdDevs
+	if !byFactor && !byStdDevs {
+		return nil
+	}
+	return r
+}
+
+// String summarizes a regression, e.g. "2.1x baseline 1.2s (+4.3σ)".
+func (r *Regression) String() string {
+	mean := (time.Duration(r.MeanMS) * time.Millisecond).Round(time.Millisecond)
+	s := fmt.Sprintf("%.1fx baseline %s", r.Ratio, mean)
+	if r.Sigma > 0 {
+		s += fmt.Sprintf(" (+%.1fσ)", r.Sigma)
+	}
+	return s
+}
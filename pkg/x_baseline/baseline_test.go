@@ -0,0 +1,79 @@
+package x_baseline
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+//
+// ---------- Helpers ----------
+
+// baseline rolls durations in milliseconds into a baseline.
+func baseline(window int, ms ...int64) Baseline {
+	var b Baseline
+	for _, d := range ms {
+		b = b.Add(time.Duration(d)*time.Millisecond, window)
+	}
+	return b
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestAdd verifies the rolling window, mean and standard deviation.
+func TestAdd(t *testing.T) {
+	b := baseline(4, 9000, 2000, 4000, 4000, 4000, 5000, 5000)
+	if len(b.SamplesMS) != 4 || b.SamplesMS[0] != 4000 || b.SamplesMS[3] != 5000 {
+		t.Fatalf("unexpected window %v", b.SamplesMS)
+	}
+	if b.MeanMS != 4500 || b.StdDevMS != 500 {
+		t.Errorf("expected mean 4500 and stddev 500, got %v and %v", b.MeanMS, b.StdDevMS)
+	}
+
+	// Rolling forward does not change the earlier baseline
+	b.Add(time.Second, 4)
+	if b.SamplesMS[0] != 4000 {
+		t.Errorf("Add modified its receiver: %v", b.SamplesMS)
+	}
+}
+
+// TestCheck verifies the factor, standard deviation and minimum checks.
+func TestCheck(t *testing.T) {
+	policy := Policy{Window: 10, MinSamples: 3, Factor: 1.5, StdDevs: 3, MinDelta: 100 * time.Millisecond}
+	stable := baseline(10, 1000, 1000, 1000, 1000)
+	noisy := baseline(10, 1000, 1200, 800, 1000)
+
+	cases := []struct {
+		name string
+		b    Baseline
+		ms   int64
+		want bool
+	}{
+		{"within", noisy, 1100, false},
+		{"by factor", noisy, 1600, true},
+		{"by stddevs", noisy, 1450, true},
+		{"stable below factor", stable, 1400, false},
+		{"below min delta", baseline(10, 100, 100, 100), 190, false},
+		{"too few samples", baseline(10, 1000, 1000), 5000, false},
+		{"faster", noisy, 500, false},
+	}
+	for _, c := range cases {
+		r := policy.Check(c.b, time.Duration(c.ms)*time.Millisecond)
+		if (r != nil) != c.want {
+			t.Errorf("%s: Check = %+v, want regression %v", c.name, r, c.want)
+		}
+	}
+
+	r := policy.Check(noisy, 1600*time.Millisecond)
+	if r.Ratio != 1.6 || math.Abs(r.Sigma-4.2426) > 0.001 || r.Samples != 4 {
+		t.Errorf("unexpected regression %+v", r)
+	}
+	if got := r.String(); got != "1.6x baseline 1s (+4.2σ)" {
+		t.Errorf("String = %q", got)
+	}
+
+	if (Policy{}).Check(noisy, time.Hour) != nil {
+		t.Errorf("expected a disabled policy to flag nothing")
+	}
+}
@@ -19,8 +19,12 @@ const (
 	DefaultConfig   = "space/cfg/jtask.json" // Default config file path
 	LocalConfig     = ".jtask.json"          // Local config file path
 	GlobalConfig    = "JTASK_CONFIG"         // Environment variable for custom config file path
+	DefaultSpace    = "space"                // Default directory for runtime state
 )
 
+// ErrNotFound is returned by LoadConfig when no config file exists at all.
+var ErrNotFound = errors.New("no config file found")
+
 //
 // ---------- Default Config ----------
 
@@ -30,6 +34,9 @@ var defaultConfig = Config{
 	Version:       "1.0.0",        // Default version
 	Logger:        x_log.Config{}, // You might want to set default logger config here
 	MaxConcurrent: 5,              // Default max concurrent tasks
+	Space:         DefaultSpace,   // Default runtime state directory
+	Retention:     Retention{MaxAgeDays: 90, MaxRuns: 1000},
+	Regression:    Regression{Window: 20, MinSamples: 5, Factor: 1.5, StdDevs: 3, MinDeltaMS: 1000},
 }
 
 //
@@ -41,6 +48,59 @@ type Config struct {
 	Version       string       `json:"Version"`       // Application version
 	Logger        x_log.Config `json:"Logger"`        // Logger configuration
 	MaxConcurrent int          `json:"MaxConcurrent"` // max number of concurrent tasks
+	Adaptive      Adaptive     `json:"Adaptive"`      // load-adaptive concurrency
+	Space         string       `json:"Space"`         // directory for locks and runtime state
+	Retention     Retention    `json:"Retention"`     // runs kept in the run store
+	Regression    Regression   `json:"Regression"`    // duration regression detection
+}
+
+// Adaptive configures load-adaptive concurrency. Zero values take the
+// defaults of the scheduler.
+type Adaptive struct {
+	Enabled        bool    `json:"Enabled"`        // turn adaptive concurrency on
+	Min            int     `json:"Min"`            // lower bound of concurrent tasks
+	Max            int     `json:"Max"`            // upper bound of concurrent tasks
+	Interval       int     `json:"Interval"`       // sampling interval in seconds
+	LoadHigh       float64 `json:"LoadHigh"`       // 1-min load per CPU that lowers concurrency
+	LoadLow        float64 `json:"LoadLow"`        // 1-min load per CPU that allows raising it
+	CPUPressure    float64 `json:"CPUPressure"`    // PSI cpu avg10 (%) that lowers concurrency
+	MemoryPressure float64 `json:"MemoryPressure"` // PSI memory avg10 (%) that lowers concurrency
+}
+
+// Retention limits the runs kept in the run store. Zero values take the
+// defaults; negative values keep runs regardless of age or count.
+type Retention struct {
+	MaxAgeDays int `json:"MaxAgeDays"` // drop runs older than this many days
+	MaxRuns    int `json:"MaxRuns"`    // keep only this many of the newest runs
+}
+
+// Regression configures the detection of tasks that ran slower than their
+// rolling duration baseline. Zero values take the defaults; a negative
+// Window disables baselines, a negative Factor or StdDevs disables that check.
+type Regression struct {
+	Window     int     `json:"Window"`     // passing executions in the baseline
+	MinSamples int     `json:"MinSamples"` // executions needed before regressions are flagged
+	Factor     float64 `json:"Factor"`     // flag durations longer than Factor times the mean
+	StdDevs    float64 `json:"StdDevs"`    // flag durations this many standard deviations above the mean
+	MinDeltaMS int     `json:"MinDeltaMS"` // ignore slow downs smaller than this many milliseconds
+}
+
+//
+// ---------- Paths ----------
+
+// LockDir returns the directory holding task and collection lock files.
+func (c *Config) LockDir() string {
+	return filepath.Join(c.Space, "lock")
+}
+
+// ScheduleStatePath returns the file recording the last fire of scheduled tasks.
+func (c *Config) ScheduleStatePath() string {
+	return filepath.Join(c.Space, "schedule", "state.json")
+}
+
+// RunsDir returns the directory of the run store.
+func (c *Config) RunsDir() string {
+	return filepath.Join(c.Space, "runs")
 }
 
 //
@@ -60,6 +120,7 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Iterate through paths and try to load the config
+	found := false
 	for _, path := range paths {
 		if path == "" {
 			continue // Skip empty paths
@@ -90,6 +151,7 @@ func LoadConfig() (*Config, error) {
 			// 	Msg("config file not found, trying next path")
 			continue
 		}
+		found = true
 
 		// Log warnings for any other errors encountered
 		// x_log.Warn().
@@ -99,9 +161,44 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// If no valid config file is found, return an error
+	if !found {
+		return nil, ErrNotFound
+	}
 	return nil, fmt.Errorf("no valid config file found")
 }
 
+//
+// ---------- Default Function ----------
+
+// Default returns the config used when no config file exists: default values
+// and a logger that only warns on the console.
+func Default() *Config {
+	cfg := defaultConfig
+	cfg.Logger = x_log.Config{Level: "warn", Style: "dark"}
+	applyDefaults(&cfg)
+	return &cfg
+}
+
+//
+// ---------- Write Function ----------
+
+// Write saves the config as indented JSON to path.
+func (c *Config) Write(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create config dir: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
 //
 // ---------- applyDefaults Function ----------
 
@@ -117,6 +214,30 @@ func applyDefaults(cfg *Config) {
 	if cfg.MaxConcurrent == 0 {
 		cfg.MaxConcurrent = defaultConfig.MaxConcurrent
 	}
+	if cfg.Space == "" {
+		cfg.Space = defaultConfig.Space
+	}
+	if cfg.Retention.MaxAgeDays == 0 {
+		cfg.Retention.MaxAgeDays = defaultConfig.Retention.MaxAgeDays
+	}
+	if cfg.Retention.MaxRuns == 0 {
+		cfg.Retention.MaxRuns = defaultConfig.Retention.MaxRuns
+	}
+	if cfg.Regression.Window == 0 {
+		cfg.Regression.Window = defaultConfig.Regression.Window
+	}
+	if cfg.Regression.MinSamples == 0 {
+		cfg.Regression.MinSamples = defaultConfig.Regression.MinSamples
+	}
+	if cfg.Regression.Factor == 0 {
+		cfg.Regression.Factor = defaultConfig.Regression.Factor
+	}
+	if cfg.Regression.StdDevs == 0 {
+		cfg.Regression.StdDevs = defaultConfig.Regression.StdDevs
+	}
+	if cfg.Regression.MinDeltaMS == 0 {
+		cfg.Regression.MinDeltaMS = defaultConfig.Regression.MinDeltaMS
+	}
 
 	// You can add any additional logic for default values here, if needed
 }
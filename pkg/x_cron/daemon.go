@@ -0,0 +1,299 @@
+package x_cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Overlap Policies ----------
+
+// Overlap defines what happens when a task fires while its previous run is still going.
+type Overlap string
+
+const (
+	OverlapSkip    Overlap = "skip"    // Drop the new run
+	OverlapQueue   Overlap = "queue"   // Start the new run when the current one finishes
+	OverlapReplace Overlap = "replace" // Cancel the current run and start the new one
+)
+
+// ParseOverlap validates an overlap policy; empty means skip.
+func ParseOverlap(s string) (Overlap, error) {
+	switch o := Overlap(strings.ToLower(strings.TrimSpace(s))); o {
+	case "":
+		return OverlapSkip, nil
+	case OverlapSkip, OverlapQueue, OverlapReplace:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unknown overlap policy %q", s)
+	}
+}
+
+//
+// ---------- Entries ----------
+
+// Entry is a scheduled task with its parsed schedule settings.
+type Entry struct {
+	Task     *x_task.Task  // Task to run
+	Schedule Schedule      // When to run it
+	Overlap  Overlap       // What to do on overlapping runs
+	CatchUp  bool          // Run once after downtime if fires were missed
+	Jitter   time.Duration // Max random delay added to every fire
+
+	runs *Serial[*x_task.Task] // Runs of the task, set by NewDaemon
+}
+
+// NewEntry builds an entry from a task's schedule fields.
+func NewEntry(t *x_task.Task) (*Entry, error) {
+	sched, err := Parse(t.Schedule, t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("task %q: %w", t.Name, err)
+	}
+	overlap, err := ParseOverlap(t.Overlap)
+	if err != nil {
+		return nil, fmt.Errorf("task %q: %w", t.Name, err)
+	}
+
+	var jitter time.Duration
+	if t.Jitter != "" {
+		if jitter, err = time.ParseDuration(t.Jitter); err != nil || jitter < 0 {
+			return nil, fmt.Errorf("task %q: invalid jitter %q", t.Name, t.Jitter)
+		}
+	}
+
+	return &Entry{
+		Task:     t,
+		Schedule: sched,
+		Overlap:  overlap,
+		CatchUp:  t.CatchUp,
+		Jitter:   jitter,
+	}, nil
+}
+
+// Entries returns an entry for every task in the collection that has a schedule.
+func Entries(c *x_task.TaskCollection) ([]*Entry, error) {
+	var entries []*Entry
+	for _, t := range c.Data {
+		if t.Schedule == "" {
+			continue
+		}
+		e, err := NewEntry(t)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// jitter returns a random delay in [0, Jitter).
+func (e *Entry) jitter() time.Duration {
+	if e.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(e.Jitter)
+}
+
+//
+// ---------- Daemon ----------
+
+// RunFunc executes a scheduled task. ctx is cancelled on shutdown or replace.
+type RunFunc func(ctx context.Context, t *x_task.Task) error
+
+// Daemon fires scheduled tasks and remembers the last fire of each task,
+// so missed fires can be caught up after downtime.
+type Daemon struct {
+	Entries   []*Entry // Scheduled tasks
+	Run       RunFunc  // Executes a task
+	StatePath string   // File recording the last fire time of every task
+
+	mu   sync.Mutex           // Guards last
+	last map[string]time.Time // Last scheduled fire time by task name
+}
+
+// NewDaemon creates a daemon for entries.
+func NewDaemon(entries []*Entry, statePath string, run RunFunc) *Daemon {
+	d := &Daemon{Entries: entries, Run: run, StatePath: statePath}
+	for _, e := range entries {
+		e.runs = &Serial[*x_task.Task]{Name: e.Task.Name, Overlap: e.Overlap, Run: d.run}
+	}
+	return d
+}
+
+// Start runs the schedule until ctx is cancelled, then waits for running tasks.
+func (d *Daemon) Start(ctx context.Context) error {
+	last, err := LoadLastFires(d.StatePath)
+	if err != nil {
+		return err
+	}
+	d.last = last
+
+	x_log.Info().
+		Int("tasks", len(d.Entries)).
+		Msg("scheduler daemon started")
+
+	var loops sync.WaitGroup
+	for _, e := range d.Entries {
+		loops.Add(1)
+		go func(e *Entry) {
+			defer loops.Done()
+			d.loop(ctx, e)
+		}(e)
+	}
+	loops.Wait()
+	d.wait()
+
+	x_log.Info().Msg("scheduler daemon stopped")
+	return nil
+}
+
+// loop waits for the fire times of one entry and fires it.
+func (d *Daemon) loop(ctx context.Context, e *Entry) {
+	name := e.Task.Name
+	now := time.Now()
+
+	last, ok := d.lastFire(name)
+	switch {
+	case !ok:
+		last = now
+	case !e.Schedule.Next(last).After(now):
+		// Fires were missed while the daemon was down
+		if e.CatchUp {
+			x_log.Info().
+				Str("task", name).
+				Time("last", last).
+				Msg("catching up missed run")
+			d.fire(ctx, e)
+		} else {
+			x_log.Info().
+				Str("task", name).
+				Time("last", last).
+				Msg("skipping missed runs")
+		}
+		last = now
+	}
+	d.setLastFire(name, last)
+
+	for {
+		next := e.Schedule.Next(last)
+		if next.IsZero() {
+			x_log.Warn().
+				Str("task", name).
+				Msg("schedule has no future fire times")
+			return
+		}
+
+		delay := time.Until(next) + e.jitter()
+		x_log.Debug().
+			Str("task", name).
+			Time("next", next).
+			Dur("delay", delay).
+			Msg("waiting for next fire")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		d.fire(ctx, e)
+		last = next
+		d.setLastFire(name, last)
+	}
+}
+
+// fire starts a run of the entry, applying its overlap policy.
+func (d *Daemon) fire(ctx context.Context, e *Entry) {
+	e.runs.Trigger(ctx, e.Task)
+}
+
+// run executes a scheduled task and logs its outcome.
+func (d *Daemon) run(ctx context.Context, t *x_task.Task) {
+	x_log.Info().
+		Str("task", t.Name).
+		Msg("scheduled run started")
+	if err := d.Run(ctx, t); err != nil {
+		x_log.Error().
+			Err(err).
+			Str("task", t.Name).
+			Msg("scheduled run failed")
+	}
+}
+
+// wait blocks until the runs of every entry finished.
+func (d *Daemon) wait() {
+	for _, e := range d.Entries {
+		e.runs.Wait()
+	}
+}
+
+//
+// ---------- Last Fire State ----------
+
+// lastFire returns the recorded last fire time of a task.
+func (d *Daemon) lastFire(name string) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t, ok := d.last[name]
+	return t, ok
+}
+
+// setLastFire records the last fire time of a task and saves the state file.
+func (d *Daemon) setLastFire(name string, t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.last[name] = t
+
+	if err := saveLastFires(d.StatePath, d.last); err != nil {
+		x_log.Error().
+			Err(err).
+			Str("path", d.StatePath).
+			Msg("failed to save schedule state")
+	}
+}
+
+// LoadLastFires reads the last fire time of every task from the state file.
+// A missing file yields an empty map.
+func LoadLastFires(path string) (map[string]time.Time, error) {
+	last := make(map[string]time.Time)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return last, nil
+		}
+		return nil, fmt.Errorf("failed to read schedule state: %w", err)
+	}
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule state %s: %w", path, err)
+	}
+	return last, nil
+}
+
+// saveLastFires writes the state file atomically.
+func saveLastFires(path string, last map[string]time.Time) error {
+	data, err := json.MarshalIndent(last, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,141 @@
+package x_cron
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Helpers ----------
+
+// blockingDaemon returns a daemon whose runs block until release is closed.
+func blockingDaemon(t *testing.T, overlap Overlap, runs *int32, release chan struct{}) (*Daemon, *Entry) {
+	t.Helper()
+	e := &Entry{Task: &x_task.Task{Name: "job"}, Overlap: overlap}
+	d := NewDaemon([]*Entry{e}, filepath.Join(t.TempDir(), "state.json"), func(ctx context.Context, _ *x_task.Task) error {
+		atomic.AddInt32(runs, 1)
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return ctx.Err()
+	})
+	return d, e
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestOverlapSkip verifies that fires during a run are dropped.
+func TestOverlapSkip(t *testing.T) {
+	var runs int32
+	release := make(chan struct{})
+	d, e := blockingDaemon(t, OverlapSkip, &runs, release)
+
+	d.fire(context.Background(), e)
+	d.fire(context.Background(), e)
+	close(release)
+	d.wait()
+
+	if runs != 1 {
+		t.Errorf("expected 1 run, got %d", runs)
+	}
+}
+
+// TestOverlapQueue verifies that a fire during a run starts after it finishes.
+func TestOverlapQueue(t *testing.T) {
+	var runs int32
+	release := make(chan struct{})
+	d, e := blockingDaemon(t, OverlapQueue, &runs, release)
+
+	d.fire(context.Background(), e)
+	d.fire(context.Background(), e)
+	d.fire(context.Background(), e)
+	close(release)
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) == 2 })
+	d.wait()
+
+	if runs != 2 {
+		t.Errorf("expected 2 runs (one queued), got %d", runs)
+	}
+}
+
+// TestOverlapReplace verifies that a fire cancels the current run.
+func TestOverlapReplace(t *testing.T) {
+	var runs int32
+	release := make(chan struct{})
+	d, e := blockingDaemon(t, OverlapReplace, &runs, release)
+
+	d.fire(context.Background(), e)
+	d.fire(context.Background(), e) // cancels the first run
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) == 2 })
+	close(release)
+	d.wait()
+}
+
+// TestCatchUp verifies that a missed fire is run once at startup.
+func TestCatchUp(t *testing.T) {
+	var runs int32
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	sched, _ := Parse("@every 1h", "")
+
+	// The last fire was three hours ago
+	if err := saveLastFires(statePath, map[string]time.Time{"job": time.Now().Add(-3 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Entry{Task: &x_task.Task{Name: "job"}, Schedule: sched, Overlap: OverlapSkip, CatchUp: true}
+	d := NewDaemon([]*Entry{e}, statePath, func(context.Context, *x_task.Task) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waitFor(t, func() bool { return atomic.LoadInt32(&runs) >= 1 })
+		cancel()
+	}()
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if runs != 1 {
+		t.Errorf("expected exactly 1 catch-up run, got %d", runs)
+	}
+	last, _ := LoadLastFires(statePath)
+	if time.Since(last["job"]) > time.Minute {
+		t.Errorf("expected last fire to be updated, got %v", last["job"])
+	}
+}
+
+// TestNewEntry checks parsing of schedule settings from a task.
+func TestNewEntry(t *testing.T) {
+	e, err := NewEntry(&x_task.Task{Name: "job", Schedule: "@every 5m", Overlap: "queue", Jitter: "30s"})
+	if err != nil {
+		t.Fatalf("NewEntry returned error: %v", err)
+	}
+	if e.Overlap != OverlapQueue || e.Jitter != 30*time.Second {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	if _, err := NewEntry(&x_task.Task{Name: "job", Schedule: "@daily", Overlap: "sometimes"}); err == nil {
+		t.Error("expected error for unknown overlap policy")
+	}
+}
@@ -0,0 +1,242 @@
+package x_cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// ---------- Schedule ----------
+
+// Schedule computes fire times of a task.
+type Schedule interface {
+	// Next returns the first fire time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// descriptors maps predefined schedules to cron expressions.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule: a 5-field cron expression ("*/5 * * * 1-5"),
+// a descriptor ("@daily"), or an interval ("@every 10m").
+// A "CRON_TZ=Europe/Berlin " or "TZ=..." prefix overrides tz; an empty tz
+// means the local time zone.
+func Parse(spec, tz string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	// Time zone prefix
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(spec, prefix); ok {
+			zone, expr, _ := strings.Cut(rest, " ")
+			tz, spec = zone, strings.TrimSpace(expr)
+			break
+		}
+	}
+	loc := time.Local
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", tz, err)
+		}
+	}
+
+	// Fixed interval
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval in %q: %w", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("interval in %q must be at least 1s", spec)
+		}
+		return every{d: d}, nil
+	}
+
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &cron{loc: loc}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %w", err)
+	}
+	// Sunday may be written as 0 or 7
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*" || fields[2] == "?"
+	s.dowAny = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+//
+// ---------- Interval Schedule ----------
+
+// every fires at a fixed interval.
+type every struct {
+	d time.Duration
+}
+
+// Next returns t plus the interval.
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(e.d)
+}
+
+//
+// ---------- Cron Schedule ----------
+
+// bits is a set of allowed values of a cron field.
+type bits uint64
+
+func (b bits) has(v int) bool { return b&(1<<uint(v)) != 0 }
+
+// cron fires at times matching a 5-field cron expression.
+type cron struct {
+	minute, hour, dom, month, dow bits
+	domAny, dowAny                bool // Whether the day fields are unrestricted
+	loc                           *time.Location
+}
+
+// Next returns the first matching minute after t, or the zero time if
+// nothing matches within five years (e.g. "0 0 30 2 *").
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.In(c.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, c.loc).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule: if both day fields are restricted,
+// a day matches when either of them matches.
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom.has(t.Day())
+	dow := c.dow.has(int(t.Weekday()))
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+//
+// ---------- Field Parsing ----------
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseField parses a comma-separated list of values, ranges and steps.
+func parseField(field string, min, max int, names map[string]int) (bits, error) {
+	var b bits
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rng != "*" && rng != "?" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(loStr, min, max, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(hiStr, min, max, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" means from 5 to the end in steps of 15
+				hi = max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			b |= 1 << uint(v)
+		}
+	}
+	return b, nil
+}
+
+// parseValue parses a number or a name within bounds.
+func parseValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, min, max)
+	}
+	return v, nil
+}
@@ -0,0 +1,65 @@
+package x_cron
+
+import (
+	"testing"
+	"time"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestParseNext verifies next fire times for common expressions.
+func TestParseNext(t *testing.T) {
+	base := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.UTC) // Friday
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)},
+		{"30 2 1 * *", time.Date(2024, 4, 1, 2, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * fri", time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"@every 10m", base.Add(10 * time.Minute)},
+	}
+
+	for _, c := range cases {
+		s, err := Parse(c.spec, "UTC")
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", c.spec, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(c.want) {
+			t.Errorf("Parse(%q).Next = %v, want %v", c.spec, got, c.want)
+		}
+	}
+}
+
+// TestParseTimezone checks that a CRON_TZ prefix overrides the time zone.
+func TestParseTimezone(t *testing.T) {
+	s, err := Parse("CRON_TZ=Asia/Tokyo 0 9 * * *", "UTC")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	base := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC) // 09:00 in Tokyo
+	want := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+	if got := s.Next(base); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+// TestParseErrors checks rejected expressions.
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * mon-xyz", "5-1 * * * *", "@every 10x", "@every 10ms"} {
+		if _, err := Parse(spec, ""); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+	if _, err := Parse("@daily", "Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+}
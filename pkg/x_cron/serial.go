@@ -0,0 +1,98 @@
+package x_cron
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+)
+
+//
+// ---------- Serial Runs ----------
+
+// Serial runs one job at a time. A job triggered while another one runs is
+// handled by the overlap policy: dropped, queued until the current job
+// finishes, or started after cancelling it. Every scheduled task of the
+// daemon runs through one, and so do the re-runs of jt watch.
+type Serial[T any] struct {
+	Name    string                           // Name used in log messages
+	Overlap Overlap                          // What to do with jobs triggered while one runs
+	Run     func(ctx context.Context, job T) // Runs a job; ctx is cancelled on shutdown or replace
+	Merge   func(queued, job T) T            // Combines a queued job with a new one; nil keeps the new one
+
+	mu      sync.Mutex         // Guards the fields below
+	running bool               // Whether a job is running
+	queued  bool               // Whether next is queued
+	next    T                  // Job started when the current one finishes
+	cancel  context.CancelFunc // Cancels the current job
+	done    chan struct{}      // Closed when the current job finishes
+	wg      sync.WaitGroup     // Tracks running jobs
+}
+
+// Trigger starts job, or applies the overlap policy if a job is running.
+func (s *Serial[T]) Trigger(ctx context.Context, job T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		switch s.Overlap {
+		case OverlapQueue:
+			x_log.Info().
+				Str("job", s.Name).
+				Msg("previous run still going, queueing")
+			if s.queued && s.Merge != nil {
+				job = s.Merge(s.next, job)
+			}
+			s.queued, s.next = true, job
+			return
+		case OverlapReplace:
+			x_log.Info().
+				Str("job", s.Name).
+				Msg("previous run still going, replacing it")
+			s.cancel()
+			done := s.done
+			s.mu.Unlock()
+			<-done
+			s.mu.Lock()
+		default:
+			x_log.Warn().
+				Str("job", s.Name).
+				Msg("previous run still going, skipping")
+			return
+		}
+	}
+	s.start(ctx, job)
+}
+
+// Wait blocks until the current job and any job queued behind it finished.
+func (s *Serial[T]) Wait() {
+	s.wg.Wait()
+}
+
+// start launches a job; the caller must hold s.mu.
+func (s *Serial[T]) start(ctx context.Context, job T) {
+	runCtx, cancel := context.WithCancel(ctx)
+	s.running = true
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	done := s.done
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.Run(runCtx, job)
+		cancel()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.running = false
+		close(done)
+
+		if s.queued && ctx.Err() == nil {
+			next := s.next
+			var zero T
+			s.queued, s.next = false, zero
+			s.start(ctx, next)
+		}
+	}()
+}
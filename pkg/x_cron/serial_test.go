@@ -0,0 +1,45 @@
+package x_cron
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestSerialMerge verifies that jobs queued behind a running job are merged
+// into one run.
+func TestSerialMerge(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		mu  sync.Mutex
+		ran [][]string
+	)
+	s := &Serial[[]string]{
+		Name:    "watch",
+		Overlap: OverlapQueue,
+		Run: func(_ context.Context, job []string) {
+			mu.Lock()
+			ran = append(ran, job)
+			first := len(ran) == 1
+			mu.Unlock()
+			if first {
+				<-release
+			}
+		},
+		Merge: func(queued, job []string) []string { return append(append([]string{}, queued...), job...) },
+	}
+
+	s.Trigger(context.Background(), []string{"build"})
+	s.Trigger(context.Background(), []string{"test"})
+	s.Trigger(context.Background(), []string{"lint"})
+	close(release)
+	s.Wait()
+
+	if len(ran) != 2 || !slices.Equal(ran[1], []string{"test", "lint"}) {
+		t.Errorf("expected the queued jobs merged into a second run, got %v", ran)
+	}
+}
@@ -0,0 +1,385 @@
+package x_edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_parser"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+// Edits rewrite only the bytes of the task being changed. Everything else in
+// the file, including key order, indentation and unknown fields, is kept.
+
+//
+// ---------- Public Functions ----------
+
+// AddTask appends a task to the "tasks" array, formatted like its siblings.
+func AddTask(data []byte, t *x_task.Task) ([]byte, error) {
+	f, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.find(t.Name) != nil {
+		return nil, fmt.Errorf("task %q already exists", t.Name)
+	}
+
+	items := f.tasks.Items
+	if len(items) == 0 {
+		// "[]" becomes a multi-line array holding the new task
+		indent := f.indentOf(f.tasks.Offset)
+		itemIndent := indent + f.unit
+		obj := f.render(nil, nil, t, itemIndent)
+		return f.replace(f.tasks.Offset, f.tasks.End, "[\n"+itemIndent+obj+"\n"+indent+"]"), nil
+	}
+
+	last := items[len(items)-1]
+	sep := ", "
+	switch {
+	case len(items) > 1:
+		sep = string(data[items[len(items)-2].End:last.Offset])
+	case bytes.Contains(data[f.tasks.Offset:last.Offset], []byte("\n")):
+		sep = ",\n" + f.indentOf(last.Offset)
+	}
+	obj := f.render(last, nil, t, f.indentOf(last.Offset))
+	return f.replace(last.End, last.End, sep+obj), nil
+}
+
+// UpdateTask replaces the task named name with t. Members of the task object
+// keep their order and formatting unless their value changed; unknown members
+// are kept. If the task is renamed, depends_on entries of other tasks follow.
+func UpdateTask(data []byte, name string, t *x_task.Task) ([]byte, error) {
+	f, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	item := f.find(name)
+	if item == nil {
+		return nil, fmt.Errorf("task %q not found", name)
+	}
+	if t.Name != name && f.find(t.Name) != nil {
+		return nil, fmt.Errorf("task %q already exists", t.Name)
+	}
+
+	out := f.replace(item.Offset, item.End, f.render(item, item, t, f.indentOf(item.Offset)))
+	if t.Name == name {
+		return out, nil
+	}
+
+	// Point dependents at the new name
+	var c x_task.TaskCollection
+	if err := json.Unmarshal(out, &c); err != nil {
+		return nil, err
+	}
+	for _, other := range c.Data {
+		renamed := false
+		for i, dep := range other.DependsOn {
+			if dep == name {
+				other.DependsOn[i] = t.Name
+				renamed = true
+			}
+		}
+		if renamed {
+			if out, err = UpdateTask(out, other.Name, other); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// RemoveTask deletes the task named name together with its separator.
+func RemoveTask(data []byte, name string) ([]byte, error) {
+	f, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := f.tasks.Items
+	for i, item := range items {
+		if item != f.find(name) {
+			continue
+		}
+		switch {
+		case i > 0:
+			return f.replace(items[i-1].End, item.End, ""), nil
+		case len(items) > 1:
+			return f.replace(item.Offset, items[1].Offset, ""), nil
+		default:
+			return f.replace(f.tasks.Offset, f.tasks.End, "[]"), nil
+		}
+	}
+	return nil, fmt.Errorf("task %q not found", name)
+}
+
+// Save writes data to path atomically, keeping the previous content in path.bak.
+func Save(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if old, err := os.ReadFile(path); err == nil {
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(path+".bak", old, mode); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("failed to write tasks file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write tasks file: %w", err)
+	}
+
+	x_log.Info().
+		Str("path", path).
+		Str("backup", path+".bak").
+		Msg("tasks file saved")
+	return nil
+}
+
+//
+// ---------- File ----------
+
+// file is a parsed tasks file.
+type file struct {
+	data  []byte
+	root  *x_parser.Node
+	tasks *x_parser.Node // The "tasks" array
+	unit  string         // One level of indentation
+}
+
+// parse locates the "tasks" array and the indentation unit of data.
+func parse(data []byte) (*file, error) {
+	root, err := x_parser.ParseTree(data)
+	if err != nil {
+		var oe *x_parser.OffsetError
+		if errors.As(err, &oe) {
+			line, col := x_parser.LineCol(data, oe.Offset)
+			return nil, fmt.Errorf("invalid tasks file at %d:%d: %v", line, col, oe.Err)
+		}
+		return nil, err
+	}
+	if !root.IsObject() {
+		return nil, fmt.Errorf("invalid tasks file: not a JSON object")
+	}
+	m := root.Get("tasks")
+	if m == nil || !m.Value.IsArray() {
+		return nil, fmt.Errorf("invalid tasks file: no \"tasks\" array")
+	}
+
+	f := &file{data: data, root: root, tasks: m.Value, unit: "  "}
+	if len(root.Members) > 0 {
+		if indent := f.indentOf(root.Members[0].Offset); indent != "" && f.onOwnLine(root.Members[0].Offset) {
+			f.unit = indent
+		}
+	}
+	return f, nil
+}
+
+// find returns the task object with the given name, or nil.
+func (f *file) find(name string) *x_parser.Node {
+	for _, item := range f.tasks.Items {
+		if !item.IsObject() {
+			continue
+		}
+		if m := item.Get("name"); m != nil && m.Value.Value == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// replace returns a copy of the data with [start, end) replaced by text.
+func (f *file) replace(start, end int64, text string) []byte {
+	out := make([]byte, 0, len(f.data)+len(text))
+	out = append(out, f.data[:start]...)
+	out = append(out, text...)
+	return append(out, f.data[end:]...)
+}
+
+// indentOf returns the leading whitespace of the line holding offset.
+func (f *file) indentOf(offset int64) string {
+	start := bytes.LastIndexByte(f.data[:offset], '\n') + 1
+	end := start
+	for end < len(f.data) && (f.data[end] == ' ' || f.data[end] == '\t') {
+		end++
+	}
+	return string(f.data[start:end])
+}
+
+// onOwnLine reports whether only whitespace precedes offset on its line.
+func (f *file) onOwnLine(offset int64) bool {
+	start := bytes.LastIndexByte(f.data[:offset], '\n') + 1
+	return int64(start+len(f.indentOf(offset))) == offset
+}
+
+// multiline reports whether a value spans several lines.
+func (f *file) multiline(n *x_parser.Node) bool {
+	return bytes.Contains(f.data[n.Offset:n.End], []byte("\n"))
+}
+
+//
+// ---------- Rendering ----------
+
+// field is a JSON member of a task as encoding/json would write it.
+type field struct {
+	key   string
+	value reflect.Value
+	omit  bool // omitempty and empty: the member should not exist
+}
+
+// taskFields lists the members of t in struct order.
+func taskFields(t *x_task.Task) []field {
+	v := reflect.ValueOf(t).Elem()
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		key, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if !sf.IsExported() || key == "-" {
+			continue
+		}
+		if key == "" {
+			key = sf.Name
+		}
+		fields = append(fields, field{
+			key:   key,
+			value: v.Field(i),
+			omit:  strings.Contains(opts, "omitempty") && v.Field(i).IsZero(),
+		})
+	}
+	return fields
+}
+
+// render writes t as a JSON object at indentation indent, copying the layout
+// of the object ref if given. Members of base that are unchanged or unknown
+// to jt are kept as written; members absent from base are only added if set.
+func (f *file) render(ref, base *x_parser.Node, t *x_task.Task, indent string) string {
+	// Layout: text after "{", between members and before "}"
+	memberIndent := indent + f.unit
+	lead, sep, trail := "\n"+memberIndent, ",\n"+memberIndent, "\n"+indent
+	inlineArrays := false
+	if ref != nil && len(ref.Members) > 0 {
+		inlineArrays = !f.multiline(ref)
+		first, last := ref.Members[0], ref.Members[len(ref.Members)-1]
+		lead = string(f.data[ref.Offset+1 : first.Offset])
+		trail = string(f.data[last.Value.End : ref.End-1])
+		if len(ref.Members) > 1 {
+			sep = string(f.data[first.Value.End:ref.Members[1].Offset])
+		} else if !f.multiline(ref) {
+			sep = ", "
+		}
+		if f.onOwnLine(first.Offset) {
+			memberIndent = f.indentOf(first.Offset)
+		}
+		for _, m := range ref.Members {
+			if m.Value.IsArray() && len(m.Value.Items) > 0 {
+				inlineArrays = !f.multiline(m.Value)
+				break
+			}
+		}
+	}
+
+	fields := taskFields(t)
+	byKey := make(map[string]field)
+	for _, fd := range fields {
+		byKey[fd.key] = fd
+	}
+
+	// Members written in another case, e.g. "Exec", stand for their field
+	old := make(map[string]*x_parser.Member)
+	var order []string
+	if base != nil {
+		for i := range base.Members {
+			m := &base.Members[i]
+			key := m.Key
+			for _, fd := range fields {
+				if strings.EqualFold(fd.key, key) {
+					key = fd.key
+					break
+				}
+			}
+			if _, seen := old[key]; !seen {
+				order = append(order, key)
+			}
+			old[key] = m
+		}
+	}
+
+	// Known members in their existing order, then new ones in struct order
+	for _, fd := range fields {
+		if _, ok := old[fd.key]; !ok {
+			order = append(order, fd.key)
+		}
+	}
+
+	var members []string
+	for _, key := range order {
+		m, fd := old[key], byKey[key]
+		switch {
+		case fd.key == "":
+			// Unknown to jt: keep as is
+			members = append(members, string(f.data[m.Offset:m.Value.End]))
+		case fd.omit, m == nil && fd.value.IsZero():
+			continue
+		case m != nil && sameValue(f.data[m.Value.Offset:m.Value.End], fd.value):
+			members = append(members, string(f.data[m.Offset:m.Value.End]))
+		case m != nil:
+			inline := !f.multiline(m.Value)
+			members = append(members, string(f.data[m.Offset:m.Value.Offset])+renderValue(fd.value, inline, memberIndent, f.unit))
+		default:
+			members = append(members, marshal(key)+": "+renderValue(fd.value, inlineArrays, memberIndent, f.unit))
+		}
+	}
+	if len(members) == 0 {
+		return "{}"
+	}
+	return "{" + lead + strings.Join(members, sep) + trail + "}"
+}
+
+// renderValue encodes a value, on one line or indented below indent.
+func renderValue(v reflect.Value, inline bool, indent, unit string) string {
+	if inline && v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = marshal(v.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	if inline {
+		return marshal(v.Interface())
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(indent, unit)
+	enc.Encode(v.Interface())
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sameValue reports whether raw JSON decodes to the same value as v.
+func sameValue(raw []byte, v reflect.Value) bool {
+	decoded := reflect.New(v.Type())
+	if err := json.Unmarshal(raw, decoded.Interface()); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(decoded.Elem().Interface(), v.Interface())
+}
+
+// marshal encodes v compactly without HTML escaping.
+func marshal(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,230 @@
+package x_edit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/rskv-p/jtask/pkg/x_validate"
+)
+
+//
+// ---------- Mock Data ----------
+
+// mockTasks is a hand-formatted tasks file with an unknown field.
+const mockTasks = `{
+    "name": "demo",
+    "x-owner": "ops",
+    "tasks": [
+        {
+            "name": "build",
+            "exec": ["go", "build"],
+            "x-note": "keep me"
+        },
+        {
+            "name": "test",
+            "description": "Run tests",
+            "exec": ["go", "test"],
+            "depends_on": ["build"]
+        }
+    ]
+}
+`
+
+//
+// ---------- Unit Tests ----------
+
+// TestAddTask verifies that a new task is formatted like its siblings.
+func TestAddTask(t *testing.T) {
+	out, err := AddTask([]byte(mockTasks), &x_task.Task{Name: "lint", Exec: []string{"go", "vet"}, Tags: []string{"ci"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `        },
+        {
+            "name": "lint",
+            "exec": ["go", "vet"],
+            "tags": ["ci"]
+        }
+    ]
+}
+`
+	if !strings.HasSuffix(string(out), want) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if !strings.HasPrefix(string(out), mockTasks[:strings.Index(mockTasks, "\n    ]")]) {
+		t.Errorf("existing content changed:\n%s", out)
+	}
+
+	if _, err := AddTask([]byte(mockTasks), &x_task.Task{Name: "build"}); err == nil {
+		t.Error("expected error for duplicate task")
+	}
+}
+
+// TestAddTaskEmpty verifies adding to an empty tasks array.
+func TestAddTaskEmpty(t *testing.T) {
+	out, err := AddTask([]byte("{\n  \"tasks\": []\n}\n"), &x_task.Task{Name: "a", Exec: []string{"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"tasks\": [\n    {\n      \"name\": \"a\",\n      \"exec\": [\n        \"true\"\n      ]\n    }\n  ]\n}\n"
+	if string(out) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// TestUpdateTask verifies that only changed members are rewritten.
+func TestUpdateTask(t *testing.T) {
+	c := loadCollection(t, mockTasks)
+	build := *c.Find("build")
+	build.Description = "Build it"
+	build.Exec = []string{"go", "build", "./..."}
+
+	out, err := UpdateTask([]byte(mockTasks), "build", &build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `        {
+            "name": "build",
+            "exec": ["go", "build", "./..."],
+            "x-note": "keep me",
+            "description": "Build it"
+        },`
+	if !strings.Contains(string(out), want) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// Unchanged tasks yield identical bytes
+	same, err := UpdateTask([]byte(mockTasks), "test", c.Find("test"))
+	if err != nil || string(same) != mockTasks {
+		t.Errorf("no-op update changed the file (%v):\n%s", err, same)
+	}
+}
+
+// TestUpdateTaskRename verifies that dependents follow a renamed task and
+// that cleared optional members are removed.
+func TestUpdateTaskRename(t *testing.T) {
+	c := loadCollection(t, mockTasks)
+	build := *c.Find("build")
+	build.Name = "compile"
+
+	out, err := UpdateTask([]byte(mockTasks), "build", &build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed := loadCollection(t, string(out))
+	if renamed.Find("compile") == nil || renamed.Find("test").DependsOn[0] != "compile" {
+		t.Errorf("rename not applied:\n%s", out)
+	}
+
+	test := *renamed.Find("test")
+	test.DependsOn = nil
+	out, err = UpdateTask(out, "test", &test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "depends_on") {
+		t.Errorf("depends_on not removed:\n%s", out)
+	}
+}
+
+// TestEditKeyCase verifies edits of a file with keys in another case, which
+// encoding/json reads and the validator checks before saving.
+func TestEditKeyCase(t *testing.T) {
+	data := "{\n  \"Name\": \"case\",\n  \"Tasks\": [\n" +
+		"    { \"Name\": \"build\", \"Exec\": [\"true\"] },\n" +
+		"    { \"Name\": \"test\", \"Exec\": [\"true\"], \"Depends_On\": [\"build\"] }\n  ]\n}\n"
+	c := loadCollection(t, data)
+
+	test := *c.Find("test")
+	test.Exec = []string{"go", "test"}
+	out, err := UpdateTask([]byte(data), "test", &test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{ "Name": "test", "Exec": ["go", "test"], "Depends_On": ["build"] }`; !strings.Contains(string(out), want) {
+		t.Errorf("expected the members to keep their keys:\n%s", out)
+	}
+	if out, err = AddTask(out, &x_task.Task{Name: "lint", Exec: []string{"true"}, DependsOn: []string{"test"}}); err != nil {
+		t.Fatal(err)
+	}
+	if out, err = RemoveTask(out, "lint"); err != nil {
+		t.Fatal(err)
+	}
+
+	diags := x_validate.Check("tasks.json", out)
+	if x_validate.HasErrors(diags) {
+		t.Errorf("unexpected errors: %v\n%s", diags, out)
+	}
+	if edited := loadCollection(t, string(out)); len(edited.Data) != 2 || edited.Find("lint") != nil {
+		t.Errorf("unexpected tasks after editing:\n%s", out)
+	}
+}
+
+// TestRemoveTask verifies removal of the first, last and only task.
+func TestRemoveTask(t *testing.T) {
+	out, err := RemoveTask([]byte(mockTasks), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\"keep me\"\n        }\n    ]") {
+		t.Errorf("unexpected output after removing last:\n%s", out)
+	}
+
+	out, err = RemoveTask([]byte(mockTasks), "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\"tasks\": [\n        {\n            \"name\": \"test\"") {
+		t.Errorf("unexpected output after removing first:\n%s", out)
+	}
+
+	out, err = RemoveTask(out, "test")
+	if err != nil || !strings.Contains(string(out), `"tasks": []`) {
+		t.Errorf("unexpected output after removing all (%v):\n%s", err, out)
+	}
+
+	if _, err := RemoveTask([]byte(mockTasks), "missing"); err == nil {
+		t.Error("expected error for missing task")
+	}
+}
+
+// TestSave verifies that the previous file is kept as a backup.
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	backup, _ := os.ReadFile(path + ".bak")
+	if string(data) != "new" || string(backup) != "old" {
+		t.Errorf("file = %q, backup = %q", data, backup)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode not preserved: %v", info.Mode())
+	}
+}
+
+//
+// ---------- Helpers ----------
+
+// loadCollection parses a tasks file.
+func loadCollection(t *testing.T, data string) *x_task.TaskCollection {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := x_task.LoadTasks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -0,0 +1,156 @@
+package x_event
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Events describe the lifecycle of a run for tools that wrap jt. Every event
+// is one JSON object with a common header; the fields of each type are
+// stable within a schema Version. New fields may be added to a version,
+// fields are never removed or changed without raising it.
+
+// Version is the schema version of the events, carried in every event.
+const Version = 1
+
+//
+// ---------- Event Types ----------
+
+// Type names an event.
+type Type string
+
+// Event types in the order a run emits them.
+const (
+	TypeRunStarted   Type = "run_started"   // A run started or was resumed
+	TypeTaskQueued   Type = "task_queued"   // A task waits for its dependencies and a slot
+	TypeTaskRetry    Type = "task_retry"    // A task that failed before is started again
+	TypeTaskStarted  Type = "task_started"  // A task's command started
+	TypeOutputLine   Type = "output_line"   // A task wrote a line to stdout or stderr
+	TypeTaskFinished Type = "task_finished" // A task succeeded, failed or was skipped
+	TypeRunFinished  Type = "run_finished"  // Every task of the run finished
+)
+
+//
+// ---------- Data Structures ----------
+
+// Event is implemented by every event type.
+type Event interface {
+	Head() *Header
+}
+
+// Header holds the fields common to every event.
+type Header struct {
+	Version int       `json:"version"` // Schema version
+	Type    Type      `json:"type"`    // Event type
+	Time    time.Time `json:"time"`    // When the event happened
+	Run     string    `json:"run"`     // Run ID
+}
+
+// NewHeader returns the header of an event of type t in run, happening now.
+func NewHeader(t Type, run string) Header {
+	return Header{Version: Version, Type: t, Time: time.Now(), Run: run}
+}
+
+// Head returns the header of an event.
+func (h *Header) Head() *Header { return h }
+
+// RunStarted is emitted when a run starts or is resumed.
+type RunStarted struct {
+	Header
+	Collection string   `json:"collection"` // Collection name
+	TasksFile  string   `json:"tasks_file"` // Tasks file of the run
+	User       string   `json:"user"`       // User who started the run
+	Tasks      []string `json:"tasks"`      // Tasks to execute, in dependency order
+	Resumed    bool     `json:"resumed"`    // Whether an earlier run is continued
+}
+
+// TaskQueued is emitted for every task a run is going to execute.
+type TaskQueued struct {
+	Header
+	Task      string   `json:"task"`                 // Task name
+	DependsOn []string `json:"depends_on,omitempty"` // Tasks that must succeed first
+}
+
+// TaskRetry is emitted before a task that failed in an earlier attempt is
+// started again.
+type TaskRetry struct {
+	Header
+	Task          string `json:"task"`           // Task name
+	Attempt       int    `json:"attempt"`        // Attempt about to start, from 2
+	PreviousError string `json:"previous_error"` // Error of the previous attempt
+}
+
+// TaskStarted is emitted when the command of a task starts.
+type TaskStarted struct {
+	Header
+	Task    string   `json:"task"`    // Task name
+	Attempt int      `json:"attempt"` // Attempt number, from 1
+	Argv    []string `json:"argv"`    // Command line
+}
+
+// OutputLine is emitted for every line a task writes.
+type OutputLine struct {
+	Header
+	Task   string `json:"task"`   // Task name
+	Stream string `json:"stream"` // "stdout" or "stderr"
+	Line   string `json:"line"`   // The line without its newline
+}
+
+// TaskFinished is emitted when a task succeeded, failed or was skipped.
+type TaskFinished struct {
+	Header
+	Task       string `json:"task"`             // Task name
+	Status     string `json:"status"`           // success, failed or skipped
+	ExitCode   int    `json:"exit_code"`        // Exit code, -1 if the command did not exit
+	Error      string `json:"error,omitempty"`  // Why the task failed or was skipped
+	DurationMS int64  `json:"duration_ms"`      // Run time in milliseconds
+	Attempt    int    `json:"attempt"`          // Attempt number, 0 if never started
+	Result     string `json:"result,omitempty"` // ID of the recorded result in the run store
+}
+
+// RunFinished is emitted when every task of a run finished.
+type RunFinished struct {
+	Header
+	Status      string `json:"status"`                // success or failed
+	DurationMS  int64  `json:"duration_ms"`           // Time since the run was created, in milliseconds
+	Succeeded   int    `json:"succeeded"`             // Tasks that succeeded
+	Failed      int    `json:"failed"`                // Tasks that failed
+	Skipped     int    `json:"skipped"`               // Tasks that were skipped
+	Regressions int    `json:"regressions,omitempty"` // Tasks that ran slower than their duration baseline
+}
+
+//
+// ---------- Public Functions ----------
+
+// Decode parses one JSON encoded event into its type.
+func Decode(data []byte) (Event, error) {
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+
+	var e Event
+	switch h.Type {
+	case TypeRunStarted:
+		e = &RunStarted{}
+	case TypeTaskQueued:
+		e = &TaskQueued{}
+	case TypeTaskRetry:
+		e = &TaskRetry{}
+	case TypeTaskStarted:
+		e = &TaskStarted{}
+	case TypeOutputLine:
+		e = &OutputLine{}
+	case TypeTaskFinished:
+		e = &TaskFinished{}
+	case TypeRunFinished:
+		e = &RunFinished{}
+	default:
+		return nil, fmt.Errorf("unknown event type %q", h.Type)
+	}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("invalid %s event: %w", h.Type, err)
+	}
+	return e, nil
+}
@@ -0,0 +1,56 @@
+package x_event
+
+import (
+	"bufio"
+	"bytes"
+	"sync"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestWriterDecode verifies that concurrently written events come out as
+// whole lines that decode into their types.
+func TestWriterDecode(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Emit(&OutputLine{Header: NewHeader(TypeOutputLine, "run"), Task: "build", Stream: "stdout", Line: "hello"})
+		}()
+	}
+	wg.Wait()
+	w.Emit(&TaskFinished{Header: NewHeader(TypeTaskFinished, "run"), Task: "build", Status: "failed", ExitCode: 2})
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		e, err := Decode(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 21 {
+		t.Fatalf("expected 21 events, got %d", len(events))
+	}
+	line, ok := events[0].(*OutputLine)
+	if !ok || line.Version != Version || line.Run != "run" || line.Line != "hello" {
+		t.Errorf("unexpected first event: %#v", events[0])
+	}
+	if f, ok := events[20].(*TaskFinished); !ok || f.ExitCode != 2 || f.Head().Type != TypeTaskFinished {
+		t.Errorf("unexpected last event: %#v", events[20])
+	}
+
+	if _, err := Decode([]byte(`{"version":1,"type":"nope"}`)); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
@@ -0,0 +1,56 @@
+package x_event
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+//
+// ---------- Sinks ----------
+
+// Sink receives the events of a run. Emit is called from the goroutines of
+// parallel tasks and must be safe for concurrent use.
+type Sink interface {
+	Emit(e Event)
+}
+
+// Writer writes events as JSON Lines, one event per line.
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error // First write error; later events are dropped
+}
+
+// NewWriter returns a sink writing JSON Lines to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Emit writes an event as one line.
+func (w *Writer) Emit(e Event) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.Write(append(line, '\n'))
+}
+
+// Err returns the first error writing events, if any.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Func adapts a function to a Sink.
+type Func func(e Event)
+
+// Emit calls f(e).
+func (f Func) Emit(e Event) { f(e) }
@@ -0,0 +1,288 @@
+package x_graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Graph ----------
+
+// Graph is a set of tasks with their last run status and critical path.
+type Graph struct {
+	Tasks    []*x_task.Task              // Tasks in dependency order
+	Last     map[string]*x_run.TaskState // Last finished state by task name
+	Critical []string                    // Longest chain of dependencies, first task first
+
+	index map[string]int // Position of every task in Tasks
+}
+
+// New builds a graph of tasks, which must be in dependency order as returned
+// by TaskCollection.Resolve. Dependencies outside tasks are ignored.
+// last holds the most recent run state of each task and may be nil.
+func New(tasks []*x_task.Task, last map[string]*x_run.TaskState) *Graph {
+	g := &Graph{Tasks: tasks, Last: last, index: make(map[string]int)}
+	for i, t := range tasks {
+		g.index[t.Name] = i
+	}
+	g.Critical = g.criticalPath()
+	return g
+}
+
+// Status returns the last run status of a task, or "" if it never ran.
+func (g *Graph) Status(name string) x_run.Status {
+	if ts := g.Last[name]; ts != nil {
+		return ts.Status
+	}
+	return ""
+}
+
+// deps returns the dependencies of a task that are part of the graph.
+func (g *Graph) deps(t *x_task.Task) []string {
+	var deps []string
+	for _, dep := range t.DependsOn {
+		if _, ok := g.index[dep]; ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// weight is the cost of a task on the critical path: its last duration,
+// or a nominal millisecond so that unknown tasks count by their number.
+func (g *Graph) weight(name string) time.Duration {
+	if ts := g.Last[name]; ts != nil && ts.Duration() > 0 {
+		return ts.Duration()
+	}
+	return time.Millisecond
+}
+
+// criticalPath returns the most expensive chain of dependencies.
+func (g *Graph) criticalPath() []string {
+	cost := make(map[string]time.Duration)
+	prev := make(map[string]string)
+	var end string
+
+	for _, t := range g.Tasks {
+		var best time.Duration
+		for _, dep := range g.deps(t) {
+			if cost[dep] > best {
+				best, prev[t.Name] = cost[dep], dep
+			}
+		}
+		cost[t.Name] = best + g.weight(t.Name)
+		if end == "" || cost[t.Name] > cost[end] {
+			end = t.Name
+		}
+	}
+
+	var path []string
+	for name := end; name != ""; name = prev[name] {
+		path = append([]string{name}, path...)
+	}
+	return path
+}
+
+// critical returns the tasks and edges ("dep->task") on the critical path.
+// A path of a single task is not highlighted.
+func (g *Graph) critical() (map[string]bool, map[string]bool) {
+	nodes, edges := make(map[string]bool), make(map[string]bool)
+	if len(g.Critical) < 2 {
+		return nodes, edges
+	}
+	for i, name := range g.Critical {
+		nodes[name] = true
+		if i > 0 {
+			edges[g.Critical[i-1]+"->"+name] = true
+		}
+	}
+	return nodes, edges
+}
+
+//
+// ---------- Rendering ----------
+
+// Formats lists the supported output formats.
+var Formats = []string{"ascii", "dot", "mermaid"}
+
+// Render writes the graph in the given format: ascii, dot or mermaid.
+func (g *Graph) Render(w io.Writer, format string) error {
+	switch format {
+	case "", "ascii":
+		return g.ascii(w)
+	case "dot":
+		return g.dot(w)
+	case "mermaid":
+		return g.mermaid(w)
+	default:
+		return fmt.Errorf("unknown format %q: use ascii, dot or mermaid", format)
+	}
+}
+
+// dotColors are Graphviz fill colors by last status.
+var dotColors = map[x_run.Status]string{
+	x_run.StatusSuccess: "palegreen",
+	x_run.StatusFailed:  "lightcoral",
+	x_run.StatusSkipped: "lightgray",
+}
+
+// dot renders the graph as Graphviz DOT, with edges from dependency to dependent.
+func (g *Graph) dot(w io.Writer) error {
+	critNodes, critEdges := g.critical()
+
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=white];\n")
+	for _, t := range g.Tasks {
+		attrs := []string{"label=" + dotQuote(t.Name)}
+		if color, ok := dotColors[g.Status(t.Name)]; ok {
+			attrs = append(attrs, "fillcolor="+color)
+		}
+		if critNodes[t.Name] {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(t.Name), strings.Join(attrs, ", "))
+	}
+	for _, t := range g.Tasks {
+		for _, dep := range g.deps(t) {
+			attrs := ""
+			if critEdges[dep+"->"+t.Name] {
+				attrs = " [color=red, penwidth=2]"
+			}
+			fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(dep), dotQuote(t.Name), attrs)
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaid renders the graph as a Mermaid flowchart.
+func (g *Graph) mermaid(w io.Writer) error {
+	critNodes, critEdges := g.critical()
+	id := func(name string) string { return fmt.Sprintf("t%d", g.index[name]) }
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, t := range g.Tasks {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id(t.Name), strings.ReplaceAll(t.Name, `"`, "#quot;"))
+	}
+
+	var edge int
+	var critLinks []string
+	for _, t := range g.Tasks {
+		for _, dep := range g.deps(t) {
+			fmt.Fprintf(&b, "  %s --> %s\n", id(dep), id(t.Name))
+			if critEdges[dep+"->"+t.Name] {
+				critLinks = append(critLinks, fmt.Sprint(edge))
+			}
+			edge++
+		}
+	}
+
+	b.WriteString("  classDef success fill:#98fb98\n")
+	b.WriteString("  classDef failed fill:#f08080\n")
+	b.WriteString("  classDef skipped fill:#d3d3d3\n")
+	b.WriteString("  classDef critical stroke:#f00,stroke-width:3px\n")
+	for _, t := range g.Tasks {
+		if status := g.Status(t.Name); status != "" {
+			fmt.Fprintf(&b, "  class %s %s\n", id(t.Name), status)
+		}
+		if critNodes[t.Name] {
+			fmt.Fprintf(&b, "  class %s critical\n", id(t.Name))
+		}
+	}
+	if len(critLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#f00,stroke-width:3px\n", strings.Join(critLinks, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// statusMarks are the terminal symbols and colors of each status.
+var statusMarks = map[x_run.Status]struct {
+	symbol string
+	color  lipgloss.Color
+}{
+	x_run.StatusSuccess: {"✔", "2"},
+	x_run.StatusFailed:  {"✘", "1"},
+	x_run.StatusSkipped: {"↷", "8"},
+	"":                  {"○", "7"},
+}
+
+// ascii renders the graph in levels: every task is one level below its
+// deepest dependency. Critical tasks are marked with "*".
+func (g *Graph) ascii(w io.Writer) error {
+	critNodes, _ := g.critical()
+
+	level := make(map[string]int)
+	depth := 0
+	for _, t := range g.Tasks {
+		for _, dep := range g.deps(t) {
+			level[t.Name] = max(level[t.Name], level[dep]+1)
+		}
+		depth = max(depth, level[t.Name])
+	}
+
+	width := 0
+	for _, t := range g.Tasks {
+		width = max(width, len(t.Name))
+	}
+
+	bold := lipgloss.NewStyle().Bold(true)
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	for l := 0; l <= depth; l++ {
+		b.WriteString(faint.Render(fmt.Sprintf("level %d", l)) + "\n")
+		for _, t := range g.Tasks {
+			if level[t.Name] != l {
+				continue
+			}
+			status := g.Status(t.Name)
+			mark := statusMarks[status]
+			line := lipgloss.NewStyle().Foreground(mark.color).Render(mark.symbol) + " "
+
+			name := fmt.Sprintf("%-*s", width, t.Name)
+			if critNodes[t.Name] {
+				line += bold.Render(name) + " *"
+			} else {
+				line += name + "  "
+			}
+			if status != "" {
+				line += " " + string(status)
+				if d := g.Last[t.Name].Duration(); d > 0 {
+					line += " " + d.Round(time.Millisecond).String()
+				}
+			}
+			if deps := g.deps(t); len(deps) > 0 {
+				line += faint.Render("  ← " + strings.Join(deps, ", "))
+			}
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	if len(g.Critical) > 1 {
+		b.WriteString("\n* critical path: " + strings.Join(g.Critical, " → ") + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+//
+// ---------- Helper Functions ----------
+
+// dotQuote quotes an identifier for DOT.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,96 @@
+package x_graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Helpers ----------
+
+// newGraph builds a diamond: gen -> (slow, fast) -> pack, with slow taking longest.
+func newGraph(t *testing.T) *Graph {
+	c := &x_task.TaskCollection{Data: []*x_task.Task{
+		{Name: "gen"},
+		{Name: "fast", DependsOn: []string{"gen"}},
+		{Name: "slow", DependsOn: []string{"gen"}},
+		{Name: "pack", DependsOn: []string{"fast", "slow"}},
+	}}
+	tasks, err := c.Resolve([]string{"pack"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := func(name string, status x_run.Status, d time.Duration) *x_run.TaskState {
+		return &x_run.TaskState{Name: name, Status: status, Started: start, Finished: start.Add(d)}
+	}
+	return New(tasks, map[string]*x_run.TaskState{
+		"gen":  state("gen", x_run.StatusSuccess, time.Second),
+		"fast": state("fast", x_run.StatusSuccess, time.Second),
+		"slow": state("slow", x_run.StatusFailed, time.Minute),
+	})
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestCriticalPath verifies that the path follows the slowest chain.
+func TestCriticalPath(t *testing.T) {
+	g := newGraph(t)
+	if got := strings.Join(g.Critical, ","); got != "gen,slow,pack" {
+		t.Errorf("critical path = %s, want gen,slow,pack", got)
+	}
+
+	// Without history the longest chain wins
+	g = New(g.Tasks, nil)
+	if len(g.Critical) != 3 || g.Critical[0] != "gen" || g.Critical[2] != "pack" {
+		t.Errorf("critical path without history = %v", g.Critical)
+	}
+}
+
+// TestRender verifies statuses and critical edges in every format.
+func TestRender(t *testing.T) {
+	g := newGraph(t)
+
+	var dot strings.Builder
+	if err := g.Render(&dot, "dot"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"slow" [label="slow", fillcolor=lightcoral, color=red, penwidth=2];`,
+		`"gen" -> "slow" [color=red, penwidth=2];`,
+		`"gen" -> "fast";`,
+		`"pack" [label="pack", color=red, penwidth=2];`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("dot output lacks %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid strings.Builder
+	if err := g.Render(&mermaid, "mermaid"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"t0 --> t1", "class t2 failed", "class t3 critical", "linkStyle 1,3 "} {
+		if !strings.Contains(mermaid.String(), want) {
+			t.Errorf("mermaid output lacks %q:\n%s", want, mermaid.String())
+		}
+	}
+
+	var ascii strings.Builder
+	if err := g.Render(&ascii, "ascii"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ascii.String(), "critical path: gen → slow → pack") {
+		t.Errorf("ascii output lacks critical path:\n%s", ascii.String())
+	}
+
+	if err := g.Render(&ascii, "svg"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,338 @@
+package x_lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+)
+
+//
+// ---------- Policies ----------
+
+// Policy defines what happens when a lock is already held by another process.
+type Policy string
+
+const (
+	PolicyNone Policy = ""     // No locking at all
+	PolicySkip Policy = "skip" // Skip the work if the lock is held
+	PolicyWait Policy = "wait" // Block until the lock is released
+	PolicyFail Policy = "fail" // Fail immediately if the lock is held
+)
+
+// ParsePolicy validates a policy string from a tasks file.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case PolicyNone, PolicySkip, PolicyWait, PolicyFail:
+		return p, nil
+	default:
+		return PolicyNone, fmt.Errorf("unknown concurrency policy %q", s)
+	}
+}
+
+//
+// ---------- Errors ----------
+
+// ErrLocked is returned when a lock is held by another process.
+var ErrLocked = errors.New("lock is held by another process")
+
+// BusyError describes who holds a lock that could not be acquired.
+type BusyError struct {
+	Name  string // Lock name
+	Owner *Owner // Current owner, if known
+}
+
+func (e *BusyError) Error() string {
+	if e.Owner == nil {
+		return fmt.Sprintf("lock %q is held by another process", e.Name)
+	}
+	return fmt.Sprintf("lock %q is held by pid %d on %s since %s",
+		e.Name, e.Owner.PID, e.Owner.Host, e.Owner.Started.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrLocked) work for BusyError.
+func (e *BusyError) Is(target error) bool { return target == ErrLocked }
+
+//
+// ---------- Data Structures ----------
+
+// Owner is the metadata recorded inside a lock file by its holder.
+type Owner struct {
+	Name    string    `json:"name"`    // Lock name
+	PID     int       `json:"pid"`     // Process ID of the holder
+	Host    string    `json:"host"`    // Hostname of the holder
+	Started time.Time `json:"started"` // When the lock was acquired
+}
+
+// Lock is an acquired file lock.
+type Lock struct {
+	Name  string   // Lock name
+	Path  string   // Lock file path
+	Owner Owner    // Metadata written to the lock file
+	file  *os.File // Open lock file holding the flock
+}
+
+// Info describes a lock file found on disk.
+type Info struct {
+	Name  string // Lock name
+	Path  string // Lock file path
+	Owner *Owner // Recorded owner, nil if the file is empty
+	Held  bool   // Whether a process currently holds the flock
+}
+
+// Stale reports whether the lock file records an owner that no longer holds it.
+func (i Info) Stale() bool { return !i.Held && i.Owner != nil }
+
+//
+// ---------- Public Functions ----------
+
+// Acquire takes the named lock in dir according to the given policy.
+// PolicyNone returns a nil lock; PolicySkip and PolicyFail return a
+// *BusyError when the lock is held, PolicyWait blocks until it is free.
+func Acquire(dir, name string, policy Policy) (*Lock, error) {
+	if policy == PolicyNone {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock dir: %w", err)
+	}
+	path := Path(dir, name)
+
+	x_log.Debug().
+		Str("lock", name).
+		Str("policy", string(policy)).
+		Msg("acquiring lock")
+
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+
+		if err := LockFile(f, true, policy == PolicyWait); err != nil {
+			f.Close()
+			if errors.Is(err, ErrLocked) {
+				owner, _ := readOwner(path)
+				x_log.Warn().
+					Str("lock", name).
+					Str("policy", string(policy)).
+					Msg("lock is busy")
+				return nil, &BusyError{Name: name, Owner: owner}
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		// The file may have been removed by Break between open and flock;
+		// in that case we hold a lock on an unlinked inode and must retry.
+		if !sameFile(f, path) {
+			f.Close()
+			continue
+		}
+
+		l := &Lock{Name: name, Path: path, file: f, Owner: newOwner(name)}
+		if err := l.writeOwner(); err != nil {
+			l.Release()
+			return nil, err
+		}
+
+		x_log.Debug().
+			Str("lock", name).
+			Int("pid", l.Owner.PID).
+			Msg("lock acquired")
+		return l, nil
+	}
+}
+
+// Release clears the owner record and unlocks the file. Safe on a nil lock.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	defer func() { l.file = nil }()
+
+	_ = l.file.Truncate(0)
+	if err := UnlockFile(l.file); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock %s: %w", l.Path, err)
+	}
+
+	x_log.Debug().
+		Str("lock", l.Name).
+		Msg("lock released")
+	return l.file.Close()
+}
+
+// List returns all lock files in dir that are held or record an owner.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read lock dir: %w", err)
+	}
+
+	var infos []Info
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".lock") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info := Info{
+			Name: strings.TrimSuffix(e.Name(), ".lock"),
+			Path: path,
+			Held: isHeld(path),
+		}
+		info.Owner, _ = readOwner(path)
+		if info.Owner != nil && info.Owner.Name != "" {
+			info.Name = info.Owner.Name
+		}
+		if info.Held || info.Owner != nil {
+			infos = append(infos, info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Inspect reports the state of the named lock in dir without taking it.
+func Inspect(dir, name string) Info {
+	path := Path(dir, name)
+	info := Info{Name: name, Path: path, Held: isHeld(path)}
+	info.Owner, _ = readOwner(path)
+	return info
+}
+
+// Break removes a stale lock file. It refuses to touch a lock that is held.
+func Break(dir, name string) error {
+	path := Path(dir, name)
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+
+	if err := LockFile(f, true, false); err != nil {
+		if errors.Is(err, ErrLocked) {
+			owner, _ := readOwner(path)
+			return &BusyError{Name: name, Owner: owner}
+		}
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer UnlockFile(f)
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+
+	x_log.Info().
+		Str("lock", name).
+		Msg("stale lock broken")
+	return nil
+}
+
+// Path returns the lock file path for a lock name.
+func Path(dir, name string) string {
+	return filepath.Join(dir, sanitize(name)+".lock")
+}
+
+// TaskLockName returns the lock name used for a single task.
+func TaskLockName(task string) string { return "task:" + task }
+
+// CollectionLockName returns the lock name used for a whole task collection.
+func CollectionLockName(collection string) string { return "collection:" + collection }
+
+//
+// ---------- Helper Functions ----------
+
+// newOwner builds owner metadata for the current process.
+func newOwner(name string) Owner {
+	host, _ := os.Hostname()
+	return Owner{
+		Name:    name,
+		PID:     os.Getpid(),
+		Host:    host,
+		Started: time.Now(),
+	}
+}
+
+// writeOwner replaces the lock file content with the owner record.
+func (l *Lock) writeOwner() error {
+	data, err := json.Marshal(l.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to encode lock owner: %w", err)
+	}
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write lock owner: %w", err)
+	}
+	if _, err := l.file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("failed to write lock owner: %w", err)
+	}
+	return nil
+}
+
+// readOwner reads the owner record from a lock file, nil if empty.
+func readOwner(path string) (*Owner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	var o Owner
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// isHeld probes whether another process holds the lock file.
+func isHeld(path string) bool {
+	f, err := os.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if err := LockFile(f, false, false); err != nil {
+		return errors.Is(err, ErrLocked)
+	}
+	UnlockFile(f)
+	return false
+}
+
+// sameFile checks that the open file is still the one at path.
+func sameFile(f *os.File, path string) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	b, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(a, b)
+}
+
+// sanitize turns a lock name into a safe, collision-free file name.
+func sanitize(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return fmt.Sprintf("%s-%08x", b.String(), h.Sum32())
+}
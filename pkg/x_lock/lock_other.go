@@ -0,0 +1,19 @@
+//go:build !unix && !windows
+
+package x_lock
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// LockFile is not supported on this platform.
+func LockFile(f *os.File, exclusive, wait bool) error {
+	return fmt.Errorf("file locks are not supported on %s", runtime.GOOS)
+}
+
+// UnlockFile is not supported on this platform.
+func UnlockFile(f *os.File) error {
+	return fmt.Errorf("file locks are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,184 @@
+package x_lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestAcquireRelease verifies that a lock records its owner and can be reacquired after release.
+func TestAcquireRelease(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir, "task:deploy", PolicyFail)
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+
+	infos, err := List(dir)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(infos) != 1 || !infos[0].Held || infos[0].Name != "task:deploy" {
+		t.Fatalf("unexpected lock list: %+v", infos)
+	}
+	if infos[0].Owner.PID != os.Getpid() {
+		t.Errorf("expected owner pid %d, got %d", os.Getpid(), infos[0].Owner.PID)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release returned error: %v", err)
+	}
+
+	again, err := Acquire(dir, "task:deploy", PolicyFail)
+	if err != nil {
+		t.Fatalf("Acquire after release returned error: %v", err)
+	}
+	again.Release()
+}
+
+// TestAcquireBusy checks the skip and fail policies against a held lock.
+func TestAcquireBusy(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir, "collection:ci", PolicyWait)
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	defer lock.Release()
+
+	for _, policy := range []Policy{PolicySkip, PolicyFail} {
+		_, err := Acquire(dir, "collection:ci", policy)
+		if !errors.Is(err, ErrLocked) {
+			t.Fatalf("policy %s: expected ErrLocked, got %v", policy, err)
+		}
+
+		var busy *BusyError
+		if !errors.As(err, &busy) || busy.Owner == nil || busy.Owner.PID != os.Getpid() {
+			t.Errorf("policy %s: expected owner in error, got %v", policy, err)
+		}
+	}
+}
+
+// TestAcquireWait verifies that the wait policy blocks until the holder releases.
+func TestAcquireWait(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir, "task:build", PolicyWait)
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+
+	acquired := make(chan *Lock)
+	go func() {
+		l, err := Acquire(dir, "task:build", PolicyWait)
+		if err != nil {
+			t.Errorf("waiting Acquire returned error: %v", err)
+		}
+		acquired <- l
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while still held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lock.Release()
+	select {
+	case l := <-acquired:
+		l.Release()
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiting Acquire did not return after release")
+	}
+}
+
+// TestBreak verifies that only stale locks can be broken.
+func TestBreak(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir, "task:held", PolicyFail)
+	if err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	defer lock.Release()
+
+	if err := Break(dir, "task:held"); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked breaking a held lock, got %v", err)
+	}
+
+	// Simulate a crashed owner: a lock file with an owner record but no flock
+	stale := `{"name":"task:stale","pid":999999,"host":"ci","started":"2024-01-01T00:00:00Z"}`
+	if err := os.WriteFile(Path(dir, "task:stale"), []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := List(dir)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	var found bool
+	for _, info := range infos {
+		if info.Name == "task:stale" {
+			found = info.Stale()
+		}
+	}
+	if !found {
+		t.Fatalf("expected stale lock in list: %+v", infos)
+	}
+
+	if err := Break(dir, "task:stale"); err != nil {
+		t.Fatalf("Break returned error: %v", err)
+	}
+	if _, err := os.Stat(Path(dir, "task:stale")); !os.IsNotExist(err) {
+		t.Errorf("expected stale lock file to be removed, got %v", err)
+	}
+}
+
+// TestParsePolicy checks accepted and rejected policy strings.
+func TestParsePolicy(t *testing.T) {
+	if p, err := ParsePolicy("Wait"); err != nil || p != PolicyWait {
+		t.Errorf("expected wait policy, got %q, %v", p, err)
+	}
+	if _, err := ParsePolicy("sometimes"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+// TestLockFile verifies shared and exclusive locks between open files.
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "segment")
+	open := func() *os.File {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+	a, b := open(), open()
+
+	if err := LockFile(a, false, false); err != nil {
+		t.Fatalf("shared LockFile returned error: %v", err)
+	}
+	if err := LockFile(b, false, false); err != nil {
+		t.Fatalf("expected shared locks to coexist, got %v", err)
+	}
+	UnlockFile(b)
+	if err := LockFile(b, true, false); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked for an exclusive lock, got %v", err)
+	}
+
+	if err := UnlockFile(a); err != nil {
+		t.Fatalf("UnlockFile returned error: %v", err)
+	}
+	if err := LockFile(b, true, false); err != nil {
+		t.Fatalf("exclusive LockFile after unlock returned error: %v", err)
+	}
+}
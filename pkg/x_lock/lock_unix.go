@@ -0,0 +1,35 @@
+//go:build unix
+
+package x_lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+//
+// ---------- flock Backend ----------
+
+// LockFile takes an advisory lock on an open file, shared or exclusive.
+// Unless wait is set it fails with ErrLocked when another open file holds
+// a conflicting lock.
+func LockFile(f *os.File, exclusive, wait bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	err := syscall.Flock(int(f.Fd()), how)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrLocked
+	}
+	return err
+}
+
+// UnlockFile releases a lock taken with LockFile.
+func UnlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
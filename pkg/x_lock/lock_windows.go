@@ -0,0 +1,43 @@
+//go:build windows
+
+package x_lock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+//
+// ---------- LockFileEx Backend ----------
+
+// LockFile takes an advisory lock on an open file, shared or exclusive.
+// Unless wait is set it fails with ErrLocked when another open file holds
+// a conflicting lock.
+func LockFile(f *os.File, exclusive, wait bool) error {
+	var flags uint32
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	if !wait {
+		flags |= windows.LOCKFILE_FAIL_IMMEDIATELY
+	}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, lockRange())
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return ErrLocked
+	}
+	return err
+}
+
+// UnlockFile releases a lock taken with LockFile.
+func UnlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRange())
+}
+
+// lockRange returns the position of the locked byte. Windows locks are
+// mandatory for the locked bytes, so a single byte far beyond any content
+// is locked: readers of an owner record or a store segment never block.
+func lockRange() *windows.Overlapped {
+	return &windows.Overlapped{Offset: ^uint32(0), OffsetHigh: ^uint32(0) >> 1}
+}
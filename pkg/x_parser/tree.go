@@ -0,0 +1,156 @@
+package x_parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+//
+// ---------- Positioned JSON Tree ----------
+
+// Node is a JSON value together with its position in the source.
+type Node struct {
+	Offset  int64    // Byte offset of the first character of the value
+	End     int64    // Byte offset just after the last character of the value
+	Members []Member // Object members in file order (objects only)
+	Items   []*Node  // Array elements (arrays only)
+	Value   any      // Scalar value; json.Delim('{') or '[' for containers
+}
+
+// Member is an object key and its value.
+type Member struct {
+	Key    string // Key name
+	Offset int64  // Byte offset of the key
+	Value  *Node  // Value of the key
+}
+
+// IsObject reports whether the node is a JSON object.
+func (n *Node) IsObject() bool { return n.Value == json.Delim('{') }
+
+// IsArray reports whether the node is a JSON array.
+func (n *Node) IsArray() bool { return n.Value == json.Delim('[') }
+
+// Get returns the last member with the given key, matched without regard
+// to case as encoding/json does when it decodes into a struct field.
+func (n *Node) Get(key string) *Member {
+	var found *Member
+	for i := range n.Members {
+		if strings.EqualFold(n.Members[i].Key, key) {
+			found = &n.Members[i]
+		}
+	}
+	return found
+}
+
+// OffsetError is a parse error at a byte offset.
+type OffsetError struct {
+	Offset int64 // Byte offset of the offending character
+	Err    error // Underlying error
+}
+
+func (e *OffsetError) Error() string { return e.Err.Error() }
+func (e *OffsetError) Unwrap() error { return e.Err }
+
+// ParseTree decodes JSON into a tree of positioned values, keeping the order
+// of object members. Errors are *OffsetError.
+func ParseTree(data []byte) (*Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// start returns the offset of the next token, skipping separators
+	start := func() int64 {
+		off := dec.InputOffset()
+		for off < int64(len(data)) {
+			switch data[off] {
+			case ' ', '\t', '\r', '\n', ',', ':':
+				off++
+				continue
+			}
+			break
+		}
+		return off
+	}
+
+	// fail converts decoder errors into offset errors
+	fail := func(err error) error {
+		var syn *json.SyntaxError
+		switch {
+		case errors.As(err, &syn):
+			// Offset counts the offending character too, unless the input ended
+			if syn.Offset >= int64(len(data)) {
+				return &OffsetError{Offset: int64(len(data)), Err: err}
+			}
+			return &OffsetError{Offset: max(syn.Offset-1, 0), Err: err}
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return &OffsetError{Offset: int64(len(data)), Err: errors.New("unexpected end of JSON input")}
+		default:
+			return &OffsetError{Offset: dec.InputOffset(), Err: err}
+		}
+	}
+
+	var value func() (*Node, error)
+	value = func() (*Node, error) {
+		n := &Node{Offset: start()}
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fail(err)
+		}
+		n.Value = tok
+
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				off := start()
+				key, err := dec.Token()
+				if err != nil {
+					return nil, fail(err)
+				}
+				v, err := value()
+				if err != nil {
+					return nil, err
+				}
+				n.Members = append(n.Members, Member{Key: key.(string), Offset: off, Value: v})
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, fail(err)
+			}
+		case json.Delim('['):
+			for dec.More() {
+				v, err := value()
+				if err != nil {
+					return nil, err
+				}
+				n.Items = append(n.Items, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, fail(err)
+			}
+		}
+		n.End = dec.InputOffset()
+		return n, nil
+	}
+
+	root, err := value()
+	if err != nil {
+		return nil, err
+	}
+	if off := start(); off < int64(len(data)) {
+		return nil, &OffsetError{Offset: off, Err: fmt.Errorf("unexpected data after top-level value")}
+	}
+	return root, nil
+}
+
+// LineCol converts a byte offset into a 1-based line and column.
+// Columns count characters, not bytes.
+func LineCol(data []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
@@ -0,0 +1,53 @@
+package x_parser
+
+import (
+	"errors"
+	"testing"
+)
+
+//
+// ---------- Unit Tests for Positioned Trees ----------
+
+// TestParseTree verifies member order and value offsets.
+func TestParseTree(t *testing.T) {
+	data := []byte("{\n  \"b\": [1, \"x\"],\n  \"a\": {\"c\": null}\n}")
+	root, err := ParseTree(data)
+	if err != nil {
+		t.Fatalf("ParseTree error: %v", err)
+	}
+
+	if !root.IsObject() || len(root.Members) != 2 || root.Members[0].Key != "b" || root.Members[1].Key != "a" {
+		t.Fatalf("unexpected members: %+v", root.Members)
+	}
+	b := root.Get("b").Value
+	if !b.IsArray() || string(data[b.Offset:b.End]) != `[1, "x"]` {
+		t.Errorf("array span = %q", data[b.Offset:b.End])
+	}
+	if x := b.Items[1]; string(data[x.Offset:x.End]) != `"x"` || x.Value != "x" {
+		t.Errorf("item span = %q", data[x.Offset:x.End])
+	}
+	if line, col := LineCol(data, root.Get("a").Offset); line != 3 || col != 3 {
+		t.Errorf("LineCol = %d:%d, want 3:3", line, col)
+	}
+	if root.Get("missing") != nil {
+		t.Error("expected nil for missing key")
+	}
+	if m := root.Get("B"); m == nil || m.Key != "b" {
+		t.Errorf("expected keys to match without regard to case, got %+v", m)
+	}
+}
+
+// TestParseTreeErrors verifies that errors carry the offending offset.
+func TestParseTreeErrors(t *testing.T) {
+	for input, offset := range map[string]int64{
+		`{"a": 1,}`:  7,
+		`{"a": 1} x`: 9,
+		`{"a": [`:    7,
+	} {
+		_, err := ParseTree([]byte(input))
+		var oe *OffsetError
+		if !errors.As(err, &oe) || oe.Offset != offset {
+			t.Errorf("ParseTree(%q) error = %v, want offset %d", input, err, offset)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package x_plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/rskv-p/jtask/pkg/x_util"
+)
+
+//
+// ---------- Data Structures ----------
+
+// Plan describes what a run would do, without executing anything.
+type Plan struct {
+	Collection string  `json:"collection"`     // Collection name
+	TasksFile  string  `json:"tasks_file"`     // Tasks file the plan was built from
+	Dir        string  `json:"dir"`            // Working directory of every task
+	Jobs       int     `json:"jobs"`           // Max number of concurrent tasks
+	Lock       *Lock   `json:"lock,omitempty"` // Collection lock, if any
+	Skip       string  `json:"skip,omitempty"` // Why the whole run would be skipped
+	Steps      []*Step `json:"steps"`          // Tasks in dependency order
+}
+
+// Step is one task of a plan.
+type Step struct {
+	Task      string   `json:"task"`                 // Task name
+	Level     int      `json:"level"`                // Tasks of one level may run at the same time
+	DependsOn []string `json:"depends_on,omitempty"` // Tasks of the plan that must succeed first
+	Argv      []string `json:"argv"`                 // Command as executed, including sudo
+	Path      string   `json:"path,omitempty"`       // Resolved executable, empty if not in PATH
+	Lock      *Lock    `json:"lock,omitempty"`       // Task lock, if any
+	Skip      string   `json:"skip,omitempty"`       // Why the task would be skipped
+}
+
+// Lock is the state of a lock a run would take.
+type Lock struct {
+	Name   string        `json:"name"`            // Lock name
+	Policy x_lock.Policy `json:"policy"`          // skip, wait or fail
+	Held   bool          `json:"held"`            // Whether another process holds it now
+	Owner  *x_lock.Owner `json:"owner,omitempty"` // Recorded holder, if held
+}
+
+// Formats lists the supported output formats.
+var Formats = []string{"text", "json"}
+
+//
+// ---------- Public Functions ----------
+
+// Build plans a run of tasks, which must be in dependency order as returned
+// by TaskCollection.Resolve. Locks are inspected in lockDir but not taken.
+func Build(c *x_task.TaskCollection, tasks []*x_task.Task, tasksFile, dir, lockDir string, jobs int) (*Plan, error) {
+	p := &Plan{Collection: c.Name, TasksFile: tasksFile, Dir: dir, Jobs: jobs}
+
+	lock, err := inspect(lockDir, x_lock.CollectionLockName(c.Name), c.Concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("collection %s: %w", c.Name, err)
+	}
+	p.Lock = lock
+	if lock != nil && lock.Held && lock.Policy == x_lock.PolicySkip {
+		p.Skip = "collection lock is held"
+	}
+
+	level := make(map[string]int)
+	for _, t := range tasks {
+		s := &Step{Task: t.Name, Argv: t.Command()}
+		for _, dep := range t.DependsOn {
+			if l, ok := level[dep]; ok {
+				s.DependsOn = append(s.DependsOn, dep)
+				s.Level = max(s.Level, l+1)
+			}
+		}
+		level[t.Name] = s.Level
+
+		// The binary of the task, not sudo wrapping it
+		if len(t.Exec) > 0 {
+			s.Path, _ = exec.LookPath(t.Exec[0])
+		}
+		if s.Lock, err = inspect(lockDir, x_lock.TaskLockName(t.Name), t.Concurrency); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if s.Lock != nil && s.Lock.Held && s.Lock.Policy == x_lock.PolicySkip {
+			s.Skip = "task lock is held"
+		}
+		p.Steps = append(p.Steps, s)
+	}
+	return p, nil
+}
+
+// Render writes the plan in the given format.
+func (p *Plan) Render(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		return p.text(w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	default:
+		return fmt.Errorf("unknown format %q (want one of: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+//
+// ---------- Helper Functions ----------
+
+// inspect returns the state of a lock taken with the given policy, or nil
+// if the policy takes no lock.
+func inspect(dir, name, policy string) (*Lock, error) {
+	pol, err := x_lock.ParsePolicy(policy)
+	if err != nil || pol == x_lock.PolicyNone {
+		return nil, err
+	}
+	info := x_lock.Inspect(dir, name)
+	l := &Lock{Name: name, Policy: pol, Held: info.Held}
+	if info.Held {
+		l.Owner = info.Owner
+	}
+	return l, nil
+}
+
+// describe explains what a run would do about a lock.
+func (l *Lock) describe() string {
+	s := fmt.Sprintf("%s (%s): ", l.Name, l.Policy)
+	if !l.Held {
+		return s + "free"
+	}
+	s += "held"
+	if l.Owner != nil {
+		s += fmt.Sprintf(" by pid %d on %s", l.Owner.PID, l.Owner.Host)
+	}
+	switch l.Policy {
+	case x_lock.PolicyWait:
+		return s + ", would wait"
+	case x_lock.PolicyFail:
+		return s + ", would fail"
+	default:
+		return s + ", would skip"
+	}
+}
+
+// text writes the plan for humans.
+func (p *Plan) text(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan for %s (%s)\n", p.Collection, p.TasksFile)
+	fmt.Fprintf(&b, "  dir   %s\n", p.Dir)
+	fmt.Fprintf(&b, "  env   inherited from jt, unchanged\n")
+	fmt.Fprintf(&b, "  jobs  %d\n", p.Jobs)
+	if p.Lock != nil {
+		fmt.Fprintf(&b, "  lock  %s\n", p.Lock.describe())
+	}
+	if p.Skip != "" {
+		fmt.Fprintf(&b, "  skip  %s, nothing would run\n", p.Skip)
+	}
+
+	for i, s := range p.Steps {
+		order := fmt.Sprintf("level %d", s.Level)
+		if len(s.DependsOn) > 0 {
+			order += ", after " + strings.Join(s.DependsOn, ", ")
+		}
+		fmt.Fprintf(&b, "\n%d. %s  [%s]\n", i+1, s.Task, order)
+		fmt.Fprintf(&b, "   argv  %s\n", x_util.JoinArgs(s.Argv))
+		switch {
+		case len(s.Argv) == 0:
+			fmt.Fprintf(&b, "   path  empty command, would fail\n")
+		case s.Path == "":
+			fmt.Fprintf(&b, "   path  %s not found in PATH, would fail\n", s.Argv[0])
+		default:
+			fmt.Fprintf(&b, "   path  %s\n", s.Path)
+		}
+		if s.Lock != nil {
+			fmt.Fprintf(&b, "   lock  %s\n", s.Lock.describe())
+		}
+		if s.Skip != "" {
+			fmt.Fprintf(&b, "   skip  %s\n", s.Skip)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,107 @@
+package x_plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Mock Data ----------
+
+// mockCollection builds a small collection: build <- test, build <- lint.
+func mockCollection() *x_task.TaskCollection {
+	return &x_task.TaskCollection{
+		Name:        "demo",
+		Concurrency: "skip",
+		Data: []*x_task.Task{
+			{Name: "build", Exec: []string{"go", "build"}, IsSudo: true},
+			{Name: "test", Exec: []string{"go", "test"}, DependsOn: []string{"build"}, Concurrency: "skip"},
+			{Name: "lint", Exec: []string{"no-such-binary-jt"}, DependsOn: []string{"build", "other"}},
+		},
+	}
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestBuild verifies argv, levels and lock inspection.
+func TestBuild(t *testing.T) {
+	lockDir := t.TempDir()
+	held, err := x_lock.Acquire(lockDir, x_lock.TaskLockName("test"), x_lock.PolicyFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+
+	c := mockCollection()
+	p, err := Build(c, c.Data, "tasks.json", "/work", lockDir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build, test, lint := p.Steps[0], p.Steps[1], p.Steps[2]
+	if strings.Join(build.Argv, " ") != "sudo go build" || build.Level != 0 {
+		t.Errorf("build step = %+v", build)
+	}
+	if want, _ := exec.LookPath("go"); build.Path != want {
+		t.Errorf("expected the path of go for the sudo task, got %q", build.Path)
+	}
+	if test.Level != 1 || test.Lock == nil || !test.Lock.Held || test.Skip == "" {
+		t.Errorf("test step = %+v", test)
+	}
+	if lint.Path != "" || strings.Join(lint.DependsOn, ",") != "build" || lint.Lock != nil {
+		t.Errorf("lint step = %+v", lint)
+	}
+	if p.Lock == nil || p.Lock.Held || p.Skip != "" {
+		t.Errorf("collection lock = %+v, skip = %q", p.Lock, p.Skip)
+	}
+
+	c.Data[0].Concurrency = "sometimes"
+	if _, err := Build(c, c.Data, "tasks.json", "/work", lockDir, 2); err == nil {
+		t.Error("expected error for invalid policy")
+	}
+}
+
+// TestRender verifies the text and JSON output.
+func TestRender(t *testing.T) {
+	c := mockCollection()
+	p, err := Build(c, c.Data, "tasks.json", "/work", t.TempDir(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text bytes.Buffer
+	if err := p.Render(&text, "text"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Plan for demo (tasks.json)",
+		"lock  collection:demo (skip): free",
+		"2. test  [level 1, after build]",
+		"argv  sudo go build",
+		"no-such-binary-jt not found in PATH",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output misses %q:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := p.Render(&out, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Plan
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded.Steps) != 3 {
+		t.Errorf("invalid JSON (%v):\n%s", err, out.String())
+	}
+
+	if err := p.Render(&out, "yaml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
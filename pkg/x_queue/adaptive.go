@@ -0,0 +1,227 @@
+package x_queue
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+)
+
+//
+// ---------- Config ----------
+
+// AdaptiveConfig controls load-adaptive concurrency.
+// Zero values are replaced with defaults when the controller starts.
+type AdaptiveConfig struct {
+	Min            int     // Lower bound of concurrent tasks
+	Max            int     // Upper bound of concurrent tasks
+	Interval       int     // Sampling interval in seconds
+	LoadHigh       float64 // 1-min load per CPU that lowers concurrency
+	LoadLow        float64 // 1-min load per CPU that allows raising it
+	CPUPressure    float64 // PSI cpu avg10 (%) that lowers concurrency
+	MemoryPressure float64 // PSI memory avg10 (%) that lowers concurrency
+}
+
+// withDefaults returns a copy of the config with missing values filled in.
+func (c AdaptiveConfig) withDefaults() AdaptiveConfig {
+	if c.Min <= 0 {
+		c.Min = 1
+	}
+	if c.Max <= 0 {
+		c.Max = runtime.NumCPU()
+	}
+	if c.Max < c.Min {
+		c.Max = c.Min
+	}
+	if c.Interval <= 0 {
+		c.Interval = 2
+	}
+	if c.LoadHigh <= 0 {
+		c.LoadHigh = 1.0
+	}
+	if c.LoadLow <= 0 || c.LoadLow >= c.LoadHigh {
+		c.LoadLow = c.LoadHigh * 0.7
+	}
+	if c.CPUPressure <= 0 {
+		c.CPUPressure = 50
+	}
+	if c.MemoryPressure <= 0 {
+		c.MemoryPressure = 20
+	}
+	return c
+}
+
+//
+// ---------- System Metrics ----------
+
+// Metrics is a snapshot of system load used to adjust concurrency.
+type Metrics struct {
+	LoadPerCPU     float64 // 1-min load average divided by the CPU count
+	CPUPressure    float64 // PSI cpu "some" avg10, -1 if unavailable
+	MemoryPressure float64 // PSI memory "some" avg10, -1 if unavailable
+}
+
+// ReadMetrics reads load average and PSI from a proc filesystem root ("/proc").
+func ReadMetrics(procDir string) (Metrics, error) {
+	load, err := readLoadAvg(filepath.Join(procDir, "loadavg"))
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	m := Metrics{
+		LoadPerCPU:     load / float64(runtime.NumCPU()),
+		CPUPressure:    -1,
+		MemoryPressure: -1,
+	}
+	// PSI is optional: older kernels or containers may not expose it
+	if v, err := readPressure(filepath.Join(procDir, "pressure", "cpu")); err == nil {
+		m.CPUPressure = v
+	}
+	if v, err := readPressure(filepath.Join(procDir, "pressure", "memory")); err == nil {
+		m.MemoryPressure = v
+	}
+	return m, nil
+}
+
+// readLoadAvg returns the 1-minute load average from /proc/loadavg.
+func readLoadAvg(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read load average: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty load average file %s", path)
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// readPressure returns the "some avg10" value from a PSI file.
+func readPressure(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "some" {
+			continue
+		}
+		for _, kv := range fields[1:] {
+			if v, ok := strings.CutPrefix(kv, "avg10="); ok {
+				return strconv.ParseFloat(v, 64)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no avg10 in %s", path)
+}
+
+//
+// ---------- Controller ----------
+
+// Adaptive raises or lowers a limiter's bound based on system load.
+type Adaptive struct {
+	cfg     AdaptiveConfig
+	limiter *Limiter
+	procDir string
+}
+
+// NewAdaptive creates a controller for limiter. The limiter's current bound
+// is clamped into the configured [Min, Max] range.
+func NewAdaptive(limiter *Limiter, cfg AdaptiveConfig) *Adaptive {
+	a := &Adaptive{
+		cfg:     cfg.withDefaults(),
+		limiter: limiter,
+		procDir: "/proc",
+	}
+	limiter.SetLimit(a.clamp(limiter.Limit()))
+	return a
+}
+
+// Start samples system load every interval until ctx is cancelled.
+func (a *Adaptive) Start(ctx context.Context) {
+	x_log.Info().
+		Int("min", a.cfg.Min).
+		Int("max", a.cfg.Max).
+		Int("limit", a.limiter.Limit()).
+		Msg("adaptive concurrency enabled")
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(a.cfg.Interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.Adjust()
+			}
+		}
+	}()
+}
+
+// Adjust reads system metrics once and moves the limit by one step.
+// It returns the new limit.
+func (a *Adaptive) Adjust() int {
+	m, err := ReadMetrics(a.procDir)
+	if err != nil {
+		x_log.Debug().
+			Err(err).
+			Msg("cannot read system load, keeping concurrency")
+		return a.limiter.Limit()
+	}
+	return a.apply(m)
+}
+
+// apply moves the limit one step down under pressure, or one step up when idle.
+func (a *Adaptive) apply(m Metrics) int {
+	current := a.limiter.Limit()
+	next := current
+
+	switch {
+	case m.LoadPerCPU > a.cfg.LoadHigh,
+		m.CPUPressure > a.cfg.CPUPressure,
+		m.MemoryPressure > a.cfg.MemoryPressure:
+		next = a.clamp(current - 1)
+	case m.LoadPerCPU < a.cfg.LoadLow &&
+		m.CPUPressure < a.cfg.CPUPressure/2 &&
+		m.MemoryPressure < a.cfg.MemoryPressure/2:
+		next = a.clamp(current + 1)
+	}
+
+	if next != current {
+		a.limiter.SetLimit(next)
+		x_log.Info().
+			Int("from", current).
+			Int("to", next).
+			Float64("load_per_cpu", m.LoadPerCPU).
+			Float64("cpu_pressure", m.CPUPressure).
+			Float64("memory_pressure", m.MemoryPressure).
+			Msg("concurrency adjusted")
+	}
+	return next
+}
+
+// clamp keeps n within the configured bounds.
+func (a *Adaptive) clamp(n int) int {
+	if n < a.cfg.Min {
+		return a.cfg.Min
+	}
+	if n > a.cfg.Max {
+		return a.cfg.Max
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package x_queue
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+//
+// ---------- Helpers ----------
+
+// writeProc creates a fake proc directory with loadavg and PSI files.
+func writeProc(t *testing.T, load, cpuAvg10, memAvg10 string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pressure"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"loadavg":         load + " 0.50 0.40 1/100 12345\n",
+		"pressure/cpu":    "some avg10=" + cpuAvg10 + " avg60=0.00 avg300=0.00 total=0\n",
+		"pressure/memory": "some avg10=" + memAvg10 + " avg60=0.00 avg300=0.00 total=0\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestReadMetrics verifies parsing of /proc/loadavg and PSI files.
+func TestReadMetrics(t *testing.T) {
+	dir := writeProc(t, "2.00", "12.50", "3.25")
+
+	m, err := ReadMetrics(dir)
+	if err != nil {
+		t.Fatalf("ReadMetrics returned error: %v", err)
+	}
+
+	if want := 2.0 / float64(runtime.NumCPU()); m.LoadPerCPU != want {
+		t.Errorf("expected load per cpu %v, got %v", want, m.LoadPerCPU)
+	}
+	if m.CPUPressure != 12.5 || m.MemoryPressure != 3.25 {
+		t.Errorf("unexpected pressure values: %+v", m)
+	}
+}
+
+// TestReadMetricsWithoutPSI checks that missing PSI files are tolerated.
+func TestReadMetricsWithoutPSI(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "loadavg"), []byte("0.10 0.10 0.10 1/1 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadMetrics(dir)
+	if err != nil {
+		t.Fatalf("ReadMetrics returned error: %v", err)
+	}
+	if m.CPUPressure != -1 || m.MemoryPressure != -1 {
+		t.Errorf("expected unavailable pressure, got %+v", m)
+	}
+}
+
+// TestAdaptiveAdjust verifies that concurrency moves within bounds with load.
+func TestAdaptiveAdjust(t *testing.T) {
+	limiter := NewLimiter(10)
+	a := NewAdaptive(limiter, AdaptiveConfig{Min: 2, Max: 4})
+
+	// The initial limit is clamped into [Min, Max]
+	if limiter.Limit() != 4 {
+		t.Fatalf("expected initial limit 4, got %d", limiter.Limit())
+	}
+
+	// High CPU pressure lowers the limit down to Min
+	a.procDir = writeProc(t, "0.00", "90.00", "0.00")
+	for i := 0; i < 5; i++ {
+		a.Adjust()
+	}
+	if limiter.Limit() != 2 {
+		t.Errorf("expected limit lowered to 2, got %d", limiter.Limit())
+	}
+
+	// An idle system raises the limit up to Max
+	a.procDir = writeProc(t, "0.00", "0.00", "0.00")
+	for i := 0; i < 5; i++ {
+		a.Adjust()
+	}
+	if limiter.Limit() != 4 {
+		t.Errorf("expected limit raised to 4, got %d", limiter.Limit())
+	}
+}
@@ -0,0 +1,179 @@
+package x_queue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Limiter ----------
+
+// Limiter bounds the number of tasks running at once.
+// The bound can be changed while tasks are running.
+type Limiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int // Max number of running tasks
+	active int // Number of currently running tasks
+}
+
+// NewLimiter creates a limiter allowing n concurrent tasks (at least 1).
+func NewLimiter(n int) *Limiter {
+	if n < 1 {
+		n = 1
+	}
+	l := &Limiter{limit: n}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire blocks until a slot is free and takes it.
+func (l *Limiter) Acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// Release frees a slot taken by Acquire.
+func (l *Limiter) Release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// SetLimit changes the number of allowed concurrent tasks (at least 1).
+// Lowering the limit never interrupts running tasks; it delays new ones.
+func (l *Limiter) SetLimit(n int) {
+	if n < 1 {
+		n = 1
+	}
+	l.mu.Lock()
+	l.limit = n
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// Limit returns the current number of allowed concurrent tasks.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// Active returns the number of currently running tasks.
+func (l *Limiter) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+//
+// ---------- Scheduler ----------
+
+// ErrDependencyFailed is reported for tasks whose dependencies did not succeed.
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// Slot describes where and since when a task waited to run.
+type Slot struct {
+	Index int       // Concurrency slot of the run the task holds, from 0
+	Ready time.Time // When its dependencies were done and it began waiting for a slot
+}
+
+// Run starts fn for every task, never running more tasks at once than the
+// limiter allows, and waits until all of them have finished.
+// A task starts only after the tasks it depends on (within tasks) succeeded;
+// if one of them failed, fn is not called and ErrDependencyFailed is reported.
+// Dependencies outside tasks are treated as satisfied. Tasks must not form a cycle.
+// The returned map holds the outcome of every task by name.
+func Run(tasks []*x_task.Task, limiter *Limiter, fn func(*x_task.Task) error) map[string]error {
+	return RunSlots(tasks, limiter, func(t *x_task.Task, _ Slot) error { return fn(t) })
+}
+
+// RunSlots runs tasks like Run and tells fn the slot it runs in: the lowest
+// slot not held by another task of this call. Tasks running at the same
+// time never share a slot.
+func RunSlots(tasks []*x_task.Task, limiter *Limiter, fn func(*x_task.Task, Slot) error) map[string]error {
+	x_log.Debug().
+		Int("tasks", len(tasks)).
+		Int("limit", limiter.Limit()).
+		Msg("scheduling tasks")
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]error, len(tasks))
+		done    = make(map[string]chan struct{}, len(tasks))
+		used    []bool // Slots held by running tasks
+		wg      sync.WaitGroup
+	)
+	for _, t := range tasks {
+		done[t.Name] = make(chan struct{})
+	}
+
+	// record stores an outcome and wakes the task's dependents
+	record := func(name string, err error) {
+		mu.Lock()
+		results[name] = err
+		mu.Unlock()
+		close(done[name])
+	}
+
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(task *x_task.Task) {
+			defer wg.Done()
+
+			// Wait for dependencies scheduled in this run
+			for _, dep := range task.DependsOn {
+				ch, ok := done[dep]
+				if !ok {
+					continue
+				}
+				<-ch
+
+				mu.Lock()
+				depErr := results[dep]
+				mu.Unlock()
+				if depErr != nil {
+					x_log.Warn().
+						Str("task", task.Name).
+						Str("dependency", dep).
+						Msg("skipping task, dependency failed")
+					record(task.Name, fmt.Errorf("%w: %s", ErrDependencyFailed, dep))
+					return
+				}
+			}
+
+			slot := Slot{Ready: time.Now()}
+			limiter.Acquire()
+			mu.Lock()
+			for slot.Index < len(used) && used[slot.Index] {
+				slot.Index++
+			}
+			if slot.Index == len(used) {
+				used = append(used, false)
+			}
+			used[slot.Index] = true
+			mu.Unlock()
+
+			err := fn(task, slot)
+
+			mu.Lock()
+			used[slot.Index] = false
+			mu.Unlock()
+			limiter.Release()
+			record(task.Name, err)
+		}(t)
+	}
+	wg.Wait()
+
+	return results
+}
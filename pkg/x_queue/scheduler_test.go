@@ -0,0 +1,146 @@
+package x_queue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Unit Test: Run ----------
+
+// TestRunRespectsLimit verifies that no more tasks than the limit run at once.
+func TestRunRespectsLimit(t *testing.T) {
+	var tasks []*x_task.Task
+	for i := 0; i < 8; i++ {
+		tasks = append(tasks, &x_task.Task{Name: fmt.Sprintf("task %d", i)})
+	}
+
+	var running, peak int32
+	var mu sync.Mutex
+	var done int
+
+	Run(tasks, NewLimiter(2), func(*x_task.Task) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return nil
+	})
+
+	if done != len(tasks) {
+		t.Errorf("expected %d tasks to run, got %d", len(tasks), done)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent tasks, got %d", peak)
+	}
+}
+
+// TestRunSlots verifies that tasks running at the same time hold
+// different slots within the limit.
+func TestRunSlots(t *testing.T) {
+	var tasks []*x_task.Task
+	for i := 0; i < 6; i++ {
+		tasks = append(tasks, &x_task.Task{Name: fmt.Sprintf("task %d", i)})
+	}
+
+	var mu sync.Mutex
+	held := make(map[int]bool)
+	RunSlots(tasks, NewLimiter(3), func(task *x_task.Task, slot Slot) error {
+		mu.Lock()
+		if held[slot.Index] || slot.Index >= 3 || slot.Ready.IsZero() {
+			t.Errorf("%s: unexpected slot %+v", task.Name, slot)
+		}
+		held[slot.Index] = true
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		held[slot.Index] = false
+		mu.Unlock()
+		return nil
+	})
+}
+
+// TestLimiterSetLimit checks that raising the limit wakes waiting tasks.
+func TestLimiterSetLimit(t *testing.T) {
+	l := NewLimiter(1)
+	l.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second Acquire succeeded above the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.SetLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("raising the limit did not release the waiting task")
+	}
+
+	if l.Active() != 2 {
+		t.Errorf("expected 2 active slots, got %d", l.Active())
+	}
+}
+
+// TestRunDependencies verifies ordering by dependencies and skipping after failures.
+func TestRunDependencies(t *testing.T) {
+	tasks := []*x_task.Task{
+		{Name: "deploy", DependsOn: []string{"test"}},
+		{Name: "test", DependsOn: []string{"build"}},
+		{Name: "build"},
+		{Name: "docs", DependsOn: []string{"external"}},
+	}
+
+	var mu sync.Mutex
+	var order []string
+	results := Run(tasks, NewLimiter(4), func(task *x_task.Task) error {
+		mu.Lock()
+		order = append(order, task.Name)
+		mu.Unlock()
+		if task.Name == "test" {
+			return errors.New("tests failed")
+		}
+		return nil
+	})
+
+	position := make(map[string]int)
+	for i, name := range order {
+		position[name] = i
+	}
+	if len(order) != 3 || position["build"] > position["test"] {
+		t.Errorf("unexpected execution order: %v", order)
+	}
+	if _, ran := position["deploy"]; ran {
+		t.Error("deploy ran although its dependency failed")
+	}
+	if !errors.Is(results["deploy"], ErrDependencyFailed) {
+		t.Errorf("expected deploy to be skipped, got %v", results["deploy"])
+	}
+	if results["build"] != nil || results["docs"] != nil {
+		t.Errorf("unexpected results: %v", results)
+	}
+}
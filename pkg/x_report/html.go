@@ -0,0 +1,405 @@
+package x_report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+)
+
+// An HTML report is a single offline file: a timeline of every execution,
+// the dependency graph as inline SVG and the output of every task with its
+// terminal colors. Styles and scripts are inlined, so the file can be
+// attached to a ticket or archived as is.
+
+//go:embed html.tmpl
+var htmlSource string
+
+// htmlTemplate renders HTML reports.
+var htmlTemplate = template.Must(template.New("report").Parse(htmlSource))
+
+//
+// ---------- View Model ----------
+
+type (
+	htmlView struct {
+		Run      *x_run.State
+		Duration string         // Wall time from the first start to the last finish
+		Peak     int            // Most tasks running at the same time
+		Counts   map[string]int // Tasks by status
+		Statuses []string       // Statuses present, for the filter
+		Ticks    []htmlTick     // Time axis of the timeline
+		Tasks    []htmlTask     // Tasks in dependency order
+		Graph    htmlGraph      // Dependency graph
+	}
+
+	htmlTask struct {
+		Name     string
+		Status   string
+		Duration string
+		Slower   string // Regression against the duration baseline, if any
+		ExitCode string
+		Attempts int
+		Deps     string
+		Error    string
+		Output   template.HTML // Output with colors as spans
+		Search   string        // Lowercase text matched by the search box
+		Bars     []htmlBar     // One bar per execution
+	}
+
+	htmlBar struct {
+		Left   float64 // Start, percent of the timeline
+		Width  float64 // Duration, percent of the timeline
+		Status string
+		Title  string
+	}
+
+	htmlTick struct {
+		Left  float64
+		Label string
+	}
+
+	htmlGraph struct {
+		Width  int
+		Height int
+		Nodes  []htmlNode
+		Edges  []htmlEdge
+	}
+
+	htmlNode struct {
+		Name   string
+		Label  string
+		Status string
+		X, Y   int
+	}
+
+	htmlEdge struct {
+		Path string
+	}
+)
+
+// Graph layout in pixels.
+const (
+	nodeWidth  = 150
+	nodeHeight = 30
+	colGap     = 60
+	rowGap     = 14
+	graphPad   = 10
+)
+
+// span is one execution of a task on the timeline.
+type span struct {
+	start, end time.Time
+	failed     bool
+}
+
+//
+// ---------- Rendering ----------
+
+// html writes the report as a self-contained HTML page.
+func (r *Report) html(w io.Writer) error {
+	return htmlTemplate.Execute(w, r.htmlView())
+}
+
+// htmlView builds the data of the HTML template.
+func (r *Report) htmlView() *htmlView {
+	run := r.Run
+	v := &htmlView{Run: run, Counts: make(map[string]int)}
+
+	// Executions of every task: recorded results, or the run state of tasks
+	// that have none
+	spans := make(map[string][]span)
+	for _, result := range r.Results {
+		if !result.Started.IsZero() && !result.Finished.IsZero() {
+			spans[result.Name] = append(spans[result.Name], span{result.Started, result.Finished, result.Error != ""})
+		}
+	}
+	var first, last time.Time
+	for _, ts := range run.Tasks {
+		if len(spans[ts.Name]) == 0 && ts.Duration() > 0 {
+			spans[ts.Name] = []span{{ts.Started, ts.Finished, ts.Status == x_run.StatusFailed}}
+		}
+		sort.Slice(spans[ts.Name], func(i, j int) bool { return spans[ts.Name][i].start.Before(spans[ts.Name][j].start) })
+		for _, s := range spans[ts.Name] {
+			if first.IsZero() || s.start.Before(first) {
+				first = s.start
+			}
+			if s.end.After(last) {
+				last = s.end
+			}
+		}
+	}
+	total := last.Sub(first)
+	v.Duration = total.Round(time.Millisecond).String()
+	v.Ticks = ticks(total)
+
+	var all []span
+	for _, ts := range run.Tasks {
+		status := string(ts.Status)
+		if v.Counts[status] == 0 {
+			v.Statuses = append(v.Statuses, status)
+		}
+		v.Counts[status]++
+
+		t := htmlTask{
+			Name:     ts.Name,
+			Status:   status,
+			Duration: "-",
+			ExitCode: "-",
+			Attempts: ts.Attempts,
+			Deps:     strings.Join(ts.DependsOn, ", "),
+			Error:    ts.Error,
+		}
+		if d := ts.Duration(); d > 0 {
+			t.Duration = d.Round(time.Millisecond).String()
+		}
+		if ts.Regression != nil {
+			t.Slower = ts.Regression.String()
+		}
+		if ts.Status == x_run.StatusSuccess || ts.Status == x_run.StatusFailed && ts.ExitCode >= 0 {
+			t.ExitCode = strconv.Itoa(ts.ExitCode)
+		}
+		output := ts.Output
+		if result := r.Result(ts); result != nil {
+			output = result.Output
+		}
+		t.Output = ansiHTML(output)
+		t.Search = strings.ToLower(ts.Name + "\n" + ts.Error + "\n" + stripANSI(output))
+
+		for i, s := range spans[ts.Name] {
+			bar := htmlBar{Status: string(x_run.StatusSuccess)}
+			if s.failed {
+				bar.Status = string(x_run.StatusFailed)
+			}
+			if total > 0 {
+				bar.Left = percent(s.start.Sub(first), total)
+				bar.Width = max(percent(s.end.Sub(s.start), total), 0.2)
+			} else {
+				bar.Width = 100
+			}
+			bar.Title = fmt.Sprintf("%s #%d: +%s, %s", ts.Name, i+1,
+				s.start.Sub(first).Round(time.Millisecond), s.end.Sub(s.start).Round(time.Millisecond))
+			t.Bars = append(t.Bars, bar)
+			all = append(all, s)
+		}
+		v.Tasks = append(v.Tasks, t)
+	}
+	v.Peak = peak(all)
+	v.Graph = layoutGraph(run.Tasks)
+	return v
+}
+
+// peak returns the most executions that overlap in time.
+func peak(spans []span) int {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	var events []event
+	for _, s := range spans {
+		events = append(events, event{s.start, 1}, event{s.end, -1})
+	}
+	// Ends sort before starts at the same instant
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+	n, best := 0, 0
+	for _, e := range events {
+		n += e.delta
+		best = max(best, n)
+	}
+	return best
+}
+
+// ticks returns about five labeled marks for a time axis of total.
+func ticks(total time.Duration) []htmlTick {
+	if total <= 0 {
+		return nil
+	}
+	step := total / 5
+	var out []htmlTick
+	for i := 0; i <= 5; i++ {
+		at := step * time.Duration(i)
+		out = append(out, htmlTick{Left: percent(at, total), Label: "+" + at.Round(time.Millisecond).String()})
+	}
+	return out
+}
+
+// layoutGraph places every task in the column of its level: one to the
+// right of its deepest dependency.
+func layoutGraph(tasks []*x_run.TaskState) htmlGraph {
+	type pos struct{ x, y int }
+	level := make(map[string]int)
+	rows := make(map[int]int)
+	at := make(map[string]pos)
+	var g htmlGraph
+
+	for _, ts := range tasks {
+		for _, dep := range ts.DependsOn {
+			if _, ok := at[dep]; ok {
+				level[ts.Name] = max(level[ts.Name], level[dep]+1)
+			}
+		}
+		l := level[ts.Name]
+		p := pos{graphPad + l*(nodeWidth+colGap), graphPad + rows[l]*(nodeHeight+rowGap)}
+		rows[l]++
+		at[ts.Name] = p
+
+		label := ts.Name
+		if runes := []rune(label); len(runes) > 20 {
+			label = string(runes[:19]) + "…"
+		}
+		g.Nodes = append(g.Nodes, htmlNode{Name: ts.Name, Label: label, Status: string(ts.Status), X: p.x, Y: p.y})
+		g.Width = max(g.Width, p.x+nodeWidth+graphPad)
+		g.Height = max(g.Height, p.y+nodeHeight+graphPad)
+	}
+
+	for _, ts := range tasks {
+		to := at[ts.Name]
+		for _, dep := range ts.DependsOn {
+			from, ok := at[dep]
+			if !ok {
+				continue
+			}
+			x1, y1 := from.x+nodeWidth, from.y+nodeHeight/2
+			x2, y2 := to.x, to.y+nodeHeight/2
+			mid := (x1 + x2) / 2
+			g.Edges = append(g.Edges, htmlEdge{Path: fmt.Sprintf("M%d %d C%d %d %d %d %d %d", x1, y1, mid, y1, mid, y2, x2, y2)})
+		}
+	}
+	return g
+}
+
+// percent returns d as a percentage of total.
+func percent(d, total time.Duration) float64 {
+	return float64(d) / float64(total) * 100
+}
+
+//
+// ---------- ANSI Colors ----------
+
+// sgr is the text style set by ANSI SGR sequences.
+type sgr struct {
+	fg, bg                   int // Palette index 0-15, -1 for the default
+	bold, faint, italic, und bool
+}
+
+// classes returns the CSS classes of a style.
+func (s sgr) classes() string {
+	var c []string
+	if s.fg >= 0 {
+		c = append(c, "fg"+strconv.Itoa(s.fg))
+	}
+	if s.bg >= 0 {
+		c = append(c, "bg"+strconv.Itoa(s.bg))
+	}
+	for _, f := range []struct {
+		on   bool
+		name string
+	}{{s.bold, "b"}, {s.faint, "f"}, {s.italic, "i"}, {s.und, "u"}} {
+		if f.on {
+			c = append(c, f.name)
+		}
+	}
+	return strings.Join(c, " ")
+}
+
+// apply updates the style with the parameters of an SGR sequence. Colors
+// beyond the 16 color palette are dropped.
+func (s *sgr) apply(params string) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		n, _ := strconv.Atoi(codes[i])
+		switch {
+		case n == 0:
+			*s = sgr{fg: -1, bg: -1}
+		case n == 1:
+			s.bold = true
+		case n == 2:
+			s.faint = true
+		case n == 3:
+			s.italic = true
+		case n == 4:
+			s.und = true
+		case n == 22:
+			s.bold, s.faint = false, false
+		case n == 23:
+			s.italic = false
+		case n == 24:
+			s.und = false
+		case n >= 30 && n <= 37:
+			s.fg = n - 30
+		case n == 39:
+			s.fg = -1
+		case n >= 40 && n <= 47:
+			s.bg = n - 40
+		case n == 49:
+			s.bg = -1
+		case n >= 90 && n <= 97:
+			s.fg = n - 90 + 8
+		case n >= 100 && n <= 107:
+			s.bg = n - 100 + 8
+		case n == 38 || n == 48:
+			// 38;5;n or 38;2;r;g;b
+			color := -1
+			if i+2 < len(codes) && codes[i+1] == "5" {
+				if c, err := strconv.Atoi(codes[i+2]); err == nil && c < 16 {
+					color = c
+				}
+				i += 2
+			} else if i+1 < len(codes) && codes[i+1] == "2" {
+				i += 4
+			}
+			if n == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// ansiHTML escapes output for HTML and turns ANSI colors into spans with
+// CSS classes. Other escape sequences are dropped.
+func ansiHTML(s string) template.HTML {
+	var b strings.Builder
+	style := sgr{fg: -1, bg: -1}
+	open := false
+
+	for {
+		loc := ansiPattern.FindStringIndex(s)
+		if loc == nil {
+			b.WriteString(template.HTMLEscapeString(s))
+			break
+		}
+		b.WriteString(template.HTMLEscapeString(s[:loc[0]]))
+		seq := s[loc[0]:loc[1]]
+		s = s[loc[1]:]
+		if !strings.HasSuffix(seq, "m") {
+			continue
+		}
+
+		style.apply(seq[2 : len(seq)-1])
+		if open {
+			b.WriteString("</span>")
+			open = false
+		}
+		if c := style.classes(); c != "" {
+			b.WriteString(`<span class="` + c + `">`)
+			open = true
+		}
+	}
+	if open {
+		b.WriteString("</span>")
+	}
+	return template.HTML(b.String())
+}
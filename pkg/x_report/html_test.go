@@ -0,0 +1,70 @@
+package x_report
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestHTML verifies the timeline, graph and output of an HTML report and
+// that it loads no external assets.
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := mockReport().Render(&buf, "html"); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<div class="bar success" style="left: 0.000%; width: 40.000%"`, // build: 1s of 2.5s
+		`<div class="bar failed" style="left: 40.000%; width: 60.000%"`, // test: 1s to 2.5s
+		`<g class="node skipped">`,
+		`<path class="edge"`,
+		`<span class="fg1">FAIL</span> test_login`,
+		`Peak parallelism: 1`,
+		`<span class="slower" title="Slower than the duration baseline">▲ 2.5x baseline 400ms (&#43;12.0σ)</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the report", want)
+		}
+	}
+	if external := regexp.MustCompile(`(src|href)="(https?:)?//`); external.MatchString(out) {
+		t.Error("expected no external assets")
+	}
+}
+
+// TestANSIHTML verifies escaping and the conversion of SGR sequences.
+func TestANSIHTML(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"a <b> & c", "a &lt;b&gt; &amp; c"},
+		{"\x1b[1;32mok\x1b[0m done", `<span class="fg2 b">ok</span> done`},
+		{"\x1b[38;5;9mred\x1b[39m\x1b[2K plain", `<span class="fg9">red</span> plain`},
+		{"\x1b[44mopen", `<span class="bg4">open</span>`},
+	}
+	for _, tt := range tests {
+		if got := string(ansiHTML(tt.in)); got != tt.want {
+			t.Errorf("ansiHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestPeak verifies the count of overlapping executions.
+func TestPeak(t *testing.T) {
+	at := func(s int) time.Time { return time.Unix(int64(s), 0) }
+	spans := []span{
+		{start: at(0), end: at(4)},
+		{start: at(1), end: at(2)},
+		{start: at(2), end: at(3)}, // starts when the second ends
+		{start: at(5), end: at(6)},
+	}
+	if got := peak(spans); got != 2 {
+		t.Errorf("peak = %d, want 2", got)
+	}
+}
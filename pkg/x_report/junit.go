@@ -0,0 +1,154 @@
+package x_report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+)
+
+// A JUnit report has one testsuite per run, named after the collection, and
+// one testcase per task. Tasks capture stdout and stderr together, so the
+// output goes to system-out and the error of a failed task to system-err.
+// A task slower than its duration baseline carries a regression property.
+
+//
+// ---------- JUnit Schema ----------
+
+type (
+	junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Name     string       `xml:"name,attr"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Skipped  int          `xml:"skipped,attr"`
+		Time     string       `xml:"time,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+
+	junitSuite struct {
+		Name       string          `xml:"name,attr"`
+		ID         string          `xml:"id,attr"`
+		Tests      int             `xml:"tests,attr"`
+		Failures   int             `xml:"failures,attr"`
+		Errors     int             `xml:"errors,attr"`
+		Skipped    int             `xml:"skipped,attr"`
+		Time       string          `xml:"time,attr"`
+		Timestamp  string          `xml:"timestamp,attr"`
+		Properties []junitProperty `xml:"properties>property"`
+		Cases      []junitCase     `xml:"testcase"`
+	}
+
+	junitProperties struct {
+		Property []junitProperty `xml:"property"`
+	}
+
+	junitProperty struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	}
+
+	junitCase struct {
+		Name       string           `xml:"name,attr"`
+		Classname  string           `xml:"classname,attr"`
+		Time       string           `xml:"time,attr"`
+		Properties *junitProperties `xml:"properties"`
+		Failure    *junitFailure    `xml:"failure"`
+		Skipped    *junitSkipped    `xml:"skipped"`
+		SystemOut  string           `xml:"system-out,omitempty"`
+		SystemErr  string           `xml:"system-err,omitempty"`
+	}
+
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+
+	junitSkipped struct {
+		Message string `xml:"message,attr"`
+	}
+)
+
+//
+// ---------- Rendering ----------
+
+// junit writes the report as JUnit XML.
+func (r *Report) junit(w io.Writer) error {
+	run := r.Run
+	suite := junitSuite{
+		Name:      run.Collection,
+		ID:        run.ID,
+		Tests:     len(run.Tasks),
+		Time:      seconds(run.Duration()),
+		Timestamp: run.Created.Format("2006-01-02T15:04:05"),
+		Properties: []junitProperty{
+			{Name: "run", Value: run.ID},
+			{Name: "tasks_file", Value: run.TasksFile},
+			{Name: "user", Value: run.User},
+			{Name: "status", Value: string(run.Status)},
+			{Name: "regressions", Value: fmt.Sprint(len(run.Regressions()))},
+		},
+	}
+
+	for _, ts := range run.Tasks {
+		c := junitCase{Name: ts.Name, Classname: run.Collection, Time: seconds(ts.Duration())}
+		if ts.Regression != nil {
+			c.Properties = &junitProperties{Property: []junitProperty{{Name: "regression", Value: ts.Regression.String()}}}
+		}
+		output := ""
+		if result := r.Result(ts); result != nil {
+			output = stripANSI(result.Output)
+		}
+
+		switch ts.Status {
+		case x_run.StatusSuccess:
+		case x_run.StatusFailed:
+			suite.Failures++
+			c.Failure = &junitFailure{Message: ts.Error, Type: "error", Text: tail(output, tailLines)}
+			if ts.ExitCode >= 0 {
+				c.Failure.Message = fmt.Sprintf("exit code %d: %s", ts.ExitCode, ts.Error)
+				c.Failure.Type = "exit_code"
+			}
+			c.SystemErr = ts.Error
+		case x_run.StatusSkipped:
+			suite.Skipped++
+			c.Skipped = &junitSkipped{Message: ts.Error}
+			if ts.Error == "" {
+				c.Skipped.Message = "lock held, the task ran elsewhere"
+			}
+		default:
+			suite.Skipped++
+			c.Skipped = &junitSkipped{Message: "not run: " + string(ts.Status)}
+		}
+		c.SystemOut = output
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	doc := junitSuites{
+		Name:     "jt",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// seconds formats a duration as JUnit seconds.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
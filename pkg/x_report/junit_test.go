@@ -0,0 +1,50 @@
+package x_report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestJUnit verifies testcases, failures, skipped markers and output.
+func TestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := mockReport().Render(&buf, "junit"); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	out := buf.String()
+
+	var doc junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, out)
+	}
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 1 || len(doc.Suites) != 1 {
+		t.Fatalf("unexpected totals: %+v", doc)
+	}
+
+	cases := doc.Suites[0].Cases
+	if cases[0].Name != "build" || cases[0].Time != "1.000" || cases[0].Failure != nil || cases[0].SystemOut != "built\n" {
+		t.Errorf("unexpected build testcase: %+v", cases[0])
+	}
+	if p := cases[0].Properties; p == nil || len(p.Property) != 1 || p.Property[0].Name != "regression" ||
+		p.Property[0].Value != "2.5x baseline 400ms (+12.0σ)" {
+		t.Errorf("unexpected build properties: %+v", p)
+	}
+	if strings.Count(out, "<properties>") != 2 {
+		t.Errorf("expected properties only on the suite and the slower task:\n%s", out)
+	}
+	if f := cases[1].Failure; f == nil || f.Type != "exit_code" || f.Message != "exit code 2: exit status 2" ||
+		!strings.Contains(f.Text, "FAIL test_login") {
+		t.Errorf("unexpected test failure: %+v", f)
+	}
+	if strings.Contains(out, "\x1b") {
+		t.Error("expected ANSI escapes to be stripped")
+	}
+	if s := cases[2].Skipped; s == nil || s.Message != "dependency failed" {
+		t.Errorf("unexpected deploy skip: %+v", s)
+	}
+}
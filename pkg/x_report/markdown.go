@@ -0,0 +1,109 @@
+package x_report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+)
+
+// A Markdown report is a job summary: a status table of the tasks and the
+// output tail of every failed task. It is appended to its file, so that it
+// can point at $GITHUB_STEP_SUMMARY or a similar CI summary file that
+// collects the summaries of several steps.
+
+// markdownIcons mark task statuses in the summary.
+var markdownIcons = map[x_run.Status]string{
+	x_run.StatusSuccess: "✅",
+	x_run.StatusFailed:  "❌",
+	x_run.StatusSkipped: "⏭️",
+	x_run.StatusPending: "⏸️",
+	x_run.StatusRunning: "⏳",
+}
+
+//
+// ---------- Rendering ----------
+
+// markdown writes the report as a Markdown job summary.
+func (r *Report) markdown(w io.Writer) error {
+	run := r.Run
+	var b strings.Builder
+
+	counts := make(map[x_run.Status]int)
+	for _, ts := range run.Tasks {
+		counts[ts.Status]++
+	}
+	fmt.Fprintf(&b, "### %s jt run `%s`: %s\n\n", markdownIcons[run.Status], run.ID, run.Status)
+	fmt.Fprintf(&b, "%d succeeded, %d failed, %d skipped in %s · collection **%s**",
+		counts[x_run.StatusSuccess], counts[x_run.StatusFailed], counts[x_run.StatusSkipped],
+		run.Duration().Round(time.Millisecond), markdownEscape(run.Collection))
+	if run.User != "" {
+		fmt.Fprintf(&b, " · started by %s", markdownEscape(run.User))
+	}
+	if n := len(run.Regressions()); n > 0 {
+		fmt.Fprintf(&b, " · ⚠️ %d slower than baseline", n)
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("| Task | Status | Duration | Exit code | Attempts |\n")
+	b.WriteString("|------|--------|---------:|----------:|---------:|\n")
+	for _, ts := range run.Tasks {
+		duration, code, attempts := "-", "-", "-"
+		if d := ts.Duration(); d > 0 {
+			duration = d.Round(time.Millisecond).String()
+		}
+		if ts.Regression != nil {
+			duration += " ⚠️ " + ts.Regression.String()
+		}
+		if ts.Status == x_run.StatusSuccess || ts.Status == x_run.StatusFailed && ts.ExitCode >= 0 {
+			code = fmt.Sprint(ts.ExitCode)
+		}
+		if ts.Attempts > 0 {
+			attempts = fmt.Sprint(ts.Attempts)
+		}
+		fmt.Fprintf(&b, "| %s | %s %s | %s | %s | %s |\n",
+			markdownEscape(ts.Name), markdownIcons[ts.Status], ts.Status, duration, code, attempts)
+	}
+
+	for _, ts := range run.Tasks {
+		if ts.Status != x_run.StatusFailed {
+			continue
+		}
+		output := ts.Output
+		if result := r.Result(ts); result != nil {
+			output = result.Output
+		}
+
+		fmt.Fprintf(&b, "\n<details open>\n<summary><b>%s</b> failed: %s</summary>\n\n",
+			htmlEscape(ts.Name), htmlEscape(ts.Error))
+		if strings.TrimSpace(output) == "" {
+			b.WriteString("_No output._\n")
+		} else {
+			fence := "```"
+			for strings.Contains(output, fence) {
+				fence += "`"
+			}
+			fmt.Fprintf(&b, "Last %d lines of output:\n\n%s\n%s\n%s\n", tailLines, fence, tail(stripANSI(output), tailLines), fence)
+		}
+		b.WriteString("\n</details>\n")
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+//
+// ---------- Helper Functions ----------
+
+// markdownEscape escapes text for a Markdown table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "`", "\\`", "*", `\*`, "_", `\_`).Replace(s)
+}
+
+// htmlEscape escapes text for inline HTML in Markdown.
+func htmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\n", " ").Replace(s)
+}
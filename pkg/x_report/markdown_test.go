@@ -0,0 +1,53 @@
+package x_report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestMarkdown verifies the status table and failure excerpts, and that
+// summaries are appended to their file.
+func TestMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("# Earlier step\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec := Spec{Format: "markdown", Path: path}
+	if err := mockReport().Write(spec); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+
+	for _, want := range []string{
+		"# Earlier step\n",
+		"### ❌ jt run `01JNCY4A00AAAAAAAAAAAAAAAA`: failed",
+		"1 succeeded, 1 failed, 1 skipped in 3s",
+		" · ⚠️ 1 slower than baseline",
+		"| build | ✅ success | 1s ⚠️ 2.5x baseline 400ms (+12.0σ) | 0 | - |",
+		"| test | ❌ failed | 1.5s | 2 | - |",
+		"| deploy | ⏭️ skipped | - | - | - |",
+		"<summary><b>test</b> failed: exit status 2</summary>",
+		"```\nok 1\nFAIL test_login\n```",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<b>build</b>") {
+		t.Error("expected no excerpt for a task that succeeded")
+	}
+
+	if err := mockReport().Write(spec); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if n := strings.Count(string(data), "### ❌ jt run"); n != 2 {
+		t.Errorf("expected two appended summaries, got %d", n)
+	}
+}
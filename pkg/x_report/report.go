@@ -0,0 +1,146 @@
+package x_report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Data Structures ----------
+
+// Report is what reports are generated from: a run and the recorded result
+// of every task execution in it.
+type Report struct {
+	Run      *x_run.State              // Run state with the status of every task
+	Results  map[string]*x_task.Result // Recorded results by result ID
+	Attempts []*x_run.Attempt          // Scheduling of every execution, in start order
+}
+
+// Spec is a requested report: a format and the file to write it to.
+type Spec struct {
+	Format string // Report format, one of Formats
+	Path   string // Output file
+}
+
+// Formats lists the supported report formats.
+var Formats = []string{"junit", "html", "markdown", "trace"}
+
+// tailLines is the number of output lines quoted for a failed task.
+const tailLines = 20
+
+//
+// ---------- Public Functions ----------
+
+// Load builds the report of a run from the run store in dir.
+func Load(dir string, state *x_run.State) (*Report, error) {
+	results, err := x_run.Results(dir, state.ID)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Run: state, Results: make(map[string]*x_task.Result, len(results))}
+	for _, result := range results {
+		r.Results[result.ID] = result
+	}
+	if r.Attempts, err = x_run.Attempts(dir, state.ID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ParseSpec parses a report flag value of the form format=path.
+func ParseSpec(s string) (Spec, error) {
+	format, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return Spec{}, fmt.Errorf("invalid report %q (want format=path)", s)
+	}
+	if !isFormat(format) {
+		return Spec{}, fmt.Errorf("unknown report format %q (want one of: %s)", format, strings.Join(Formats, ", "))
+	}
+	return Spec{Format: format, Path: path}, nil
+}
+
+// Result returns the result of the last execution of a task, or nil if the
+// task never ran.
+func (r *Report) Result(ts *x_run.TaskState) *x_task.Result {
+	if ts.Result == "" {
+		return nil
+	}
+	return r.Results[ts.Result]
+}
+
+// Render writes the report in format to w.
+func (r *Report) Render(w io.Writer, format string) error {
+	switch format {
+	case "junit":
+		return r.junit(w)
+	case "html":
+		return r.html(w)
+	case "markdown":
+		return r.markdown(w)
+	case "trace":
+		return r.trace(w)
+	default:
+		return fmt.Errorf("unknown report format %q (want one of: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Write renders the report to the file of spec, creating its directory.
+// Markdown summaries are appended; other formats replace the file.
+func (r *Report) Write(spec Spec) error {
+	if err := os.MkdirAll(filepath.Dir(spec.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create report dir: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if spec.Format == "markdown" {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(spec.Path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s report: %w", spec.Format, err)
+	}
+	err = r.Render(f, spec.Format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s report %s: %w", spec.Format, spec.Path, err)
+	}
+	return nil
+}
+
+//
+// ---------- Helper Functions ----------
+
+// isFormat checks whether format is a supported report format.
+func isFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ansiPattern matches terminal escape sequences such as colors.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// stripANSI removes terminal escape sequences from output.
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,82 @@
+package x_report
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_baseline"
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Mock Data ----------
+
+// mockReport builds a finished run: build succeeded slower than its
+// baseline, test failed with exit code 2 and colored output, deploy was
+// skipped.
+func mockReport() *Report {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	return &Report{
+		Run: &x_run.State{
+			ID:         "01JNCY4A00AAAAAAAAAAAAAAAA",
+			Collection: "demo",
+			TasksFile:  "tasks.json",
+			User:       "ci",
+			Status:     x_run.StatusFailed,
+			Created:    start,
+			Updated:    start.Add(3 * time.Second),
+			Tasks: []*x_run.TaskState{
+				{Name: "build", Status: x_run.StatusSuccess, Result: "r1", Started: start, Finished: start.Add(time.Second),
+					Regression: &x_baseline.Regression{DurationMS: 1000, MeanMS: 400, StdDevMS: 50, Samples: 5, Ratio: 2.5, Sigma: 12}},
+				{Name: "test", DependsOn: []string{"build"}, Status: x_run.StatusFailed, Result: "r2", ExitCode: 2, Error: "exit status 2",
+					Started: start.Add(time.Second), Finished: start.Add(2500 * time.Millisecond)},
+				{Name: "deploy", DependsOn: []string{"test"}, Status: x_run.StatusSkipped, Error: "dependency failed"},
+			},
+		},
+		Results: map[string]*x_task.Result{
+			"r1": {ID: "r1", Name: "build", Output: "built\n"},
+			"r2": {ID: "r2", Name: "test", Output: "ok 1\n\x1b[31mFAIL\x1b[0m test_login\n", ExitCode: 2},
+		},
+	}
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestParseSpec verifies format=path parsing.
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec("junit=out/report.xml")
+	if err != nil || spec.Format != "junit" || spec.Path != "out/report.xml" {
+		t.Errorf("ParseSpec = %+v, %v", spec, err)
+	}
+	for _, bad := range []string{"junit", "junit=", "pdf=run.pdf"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q): expected error", bad)
+		}
+	}
+}
+
+// TestLoad verifies that a report is built from the run store.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	collection := &x_task.TaskCollection{
+		Name: "demo",
+		Data: []*x_task.Task{{Name: "hello", Exec: []string{"echo", "hello"}}},
+	}
+	runner := x_run.New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	state, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(runner.RunsDir, state)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if result := r.Result(state.Task("hello")); result == nil || result.Output != "hello\n" {
+		t.Errorf("expected the result of hello, got %+v", result)
+	}
+}
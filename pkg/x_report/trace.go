@@ -0,0 +1,135 @@
+package x_report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// A trace is a Chrome Trace Event file that opens in chrome://tracing and
+// ui.perfetto.dev. Every concurrency slot of the run is a thread track with
+// a span per task attempt, which nests a span for the wait on the task lock
+// and one for the command, named "retry #n" for attempts after the first.
+// Waits for a free slot overlap, so they are async spans of their own.
+// Tasks have no steps of their own, so a command span is not divided
+// further.
+
+//
+// ---------- Trace Event Format ----------
+
+type (
+	traceFile struct {
+		Events          []traceEvent      `json:"traceEvents"`
+		DisplayTimeUnit string            `json:"displayTimeUnit"`
+		OtherData       map[string]string `json:"otherData"`
+	}
+
+	traceEvent struct {
+		Name string         `json:"name"`
+		Cat  string         `json:"cat,omitempty"`
+		Ph   string         `json:"ph"`            // X complete, b/e async begin/end, M metadata
+		Ts   int64          `json:"ts"`            // Microseconds since the run was created
+		Dur  int64          `json:"dur,omitempty"` // Duration of X events in microseconds
+		Pid  int            `json:"pid"`
+		Tid  int            `json:"tid"`
+		ID   string         `json:"id,omitempty"` // Pairs async begin and end events
+		Args map[string]any `json:"args,omitempty"`
+	}
+)
+
+// Tracks of the trace; slot n is thread slotTid+n.
+const (
+	tracePid = 1
+	runTid   = 0
+	slotTid  = 1
+)
+
+// minWait is the shortest wait for a slot or lock that gets a span.
+const minWait = time.Millisecond
+
+//
+// ---------- Rendering ----------
+
+// trace writes the report as a Chrome Trace Event file.
+func (r *Report) trace(w io.Writer) error {
+	run := r.Run
+	origin := run.Created
+	micros := func(t time.Time) int64 { return t.Sub(origin).Microseconds() }
+
+	var events []traceEvent
+	// span adds a complete event from start to end, at least 1µs long
+	span := func(name, cat string, tid int, start, end time.Time, args map[string]any) {
+		ts := micros(start)
+		events = append(events, traceEvent{
+			Name: name, Cat: cat, Ph: "X", Ts: ts, Dur: max(micros(end)-ts, 1),
+			Pid: tracePid, Tid: tid, Args: args,
+		})
+	}
+
+	span("run "+run.ID, "run", runTid, run.Created, run.Updated, map[string]any{
+		"collection": run.Collection,
+		"tasks_file": run.TasksFile,
+		"user":       run.User,
+		"status":     string(run.Status),
+	})
+
+	slots := 0
+	for _, a := range r.Attempts {
+		slots = max(slots, a.Slot+1)
+		tid := slotTid + a.Slot
+		args := map[string]any{"attempt": a.Number, "status": string(a.Status)}
+		result := r.Results[a.Result]
+		if result != nil {
+			args["exit_code"] = result.ExitCode
+			if result.Error != "" {
+				args["error"] = result.Error
+			}
+		}
+		span(a.Task, "task", tid, a.Slotted, a.Finished, args)
+
+		if a.Locked.Sub(a.Slotted) >= minWait {
+			span("lock wait", "lock", tid, a.Slotted, a.Locked, map[string]any{"task": a.Task})
+		}
+
+		name := "command"
+		if a.Number > 1 {
+			name = fmt.Sprintf("retry #%d", a.Number-1)
+		}
+		start, end := a.Locked, a.Finished
+		if result != nil && !result.Started.IsZero() && !result.Finished.IsZero() {
+			start, end = result.Started, result.Finished
+		}
+		span(name, "exec", tid, start, end, map[string]any{"task": a.Task, "attempt": a.Number})
+
+		if !a.Ready.IsZero() && a.Slotted.Sub(a.Ready) >= minWait {
+			id := fmt.Sprintf("%s#%d", a.Task, a.Number)
+			events = append(events,
+				traceEvent{Name: "slot wait", Cat: "queue", Ph: "b", Ts: micros(a.Ready), Pid: tracePid, Tid: tid, ID: id,
+					Args: map[string]any{"task": a.Task}},
+				traceEvent{Name: "slot wait", Cat: "queue", Ph: "e", Ts: micros(a.Slotted), Pid: tracePid, Tid: tid, ID: id})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Ts < events[j].Ts })
+
+	// Name and order the tracks
+	meta := []traceEvent{
+		{Name: "process_name", Ph: "M", Pid: tracePid, Args: map[string]any{"name": fmt.Sprintf("jt %s (%s)", run.ID, run.Collection)}},
+		{Name: "thread_name", Ph: "M", Pid: tracePid, Tid: runTid, Args: map[string]any{"name": "run"}},
+		{Name: "thread_sort_index", Ph: "M", Pid: tracePid, Tid: runTid, Args: map[string]any{"sort_index": runTid}},
+	}
+	for i := range slots {
+		meta = append(meta,
+			traceEvent{Name: "thread_name", Ph: "M", Pid: tracePid, Tid: slotTid + i, Args: map[string]any{"name": fmt.Sprintf("slot %d", i+1)}},
+			traceEvent{Name: "thread_sort_index", Ph: "M", Pid: tracePid, Tid: slotTid + i, Args: map[string]any{"sort_index": slotTid + i}})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(traceFile{
+		Events:          append(meta, events...),
+		DisplayTimeUnit: "ms",
+		OtherData:       map[string]string{"run": run.ID, "started": run.Created.Format(time.RFC3339Nano)},
+	})
+}
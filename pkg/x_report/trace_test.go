@@ -0,0 +1,91 @@
+package x_report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+)
+
+//
+// ---------- Test Helpers ----------
+
+// renderTrace renders a report as a trace and parses it back.
+func renderTrace(t *testing.T, r *Report) traceFile {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.Render(&buf, "trace"); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	var trace traceFile
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatalf("trace is not valid JSON: %v", err)
+	}
+	return trace
+}
+
+// findEvent returns the first event with name and phase, or nil.
+func findEvent(trace traceFile, name, ph string) *traceEvent {
+	for i, e := range trace.Events {
+		if e.Name == name && e.Ph == ph {
+			return &trace.Events[i]
+		}
+	}
+	return nil
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestTrace verifies slot tracks, nested lock and retry spans and slot
+// waits from recorded attempts.
+func TestTrace(t *testing.T) {
+	r := mockReport()
+	start := r.Run.Created
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	r.Attempts = []*x_run.Attempt{
+		{Task: "build", Number: 1, Slot: 0, Ready: at(0), Slotted: at(0), Locked: at(200), Finished: at(1000), Status: x_run.StatusSuccess, Result: "r1"},
+		{Task: "test", Number: 1, Slot: 1, Ready: at(1000), Slotted: at(1100), Locked: at(1100), Finished: at(1500), Status: x_run.StatusFailed},
+		{Task: "test", Number: 2, Slot: 1, Ready: at(1500), Slotted: at(1500), Locked: at(1500), Finished: at(2500), Status: x_run.StatusFailed, Result: "r2"},
+	}
+	trace := renderTrace(t, r)
+
+	if trace.DisplayTimeUnit != "ms" || trace.OtherData["run"] != r.Run.ID {
+		t.Errorf("unexpected trace header: %q, %v", trace.DisplayTimeUnit, trace.OtherData)
+	}
+	names := make(map[int]string)
+	for _, e := range trace.Events {
+		if e.Name == "thread_name" {
+			names[e.Tid] = e.Args["name"].(string)
+		}
+	}
+	if names[runTid] != "run" || names[slotTid] != "slot 1" || names[slotTid+1] != "slot 2" || len(names) != 3 {
+		t.Errorf("unexpected tracks: %v", names)
+	}
+
+	build := findEvent(trace, "build", "X")
+	if build == nil || build.Tid != slotTid || build.Ts != 0 || build.Dur != 1_000_000 {
+		t.Fatalf("unexpected build span: %+v", build)
+	}
+	if build.Args["exit_code"] != float64(0) || build.Args["status"] != "success" {
+		t.Errorf("unexpected build args: %v", build.Args)
+	}
+	if lock := findEvent(trace, "lock wait", "X"); lock == nil || lock.Tid != slotTid || lock.Ts != 0 || lock.Dur != 200_000 {
+		t.Errorf("unexpected lock wait: %+v", lock)
+	}
+	if retry := findEvent(trace, "retry #1", "X"); retry == nil || retry.Tid != slotTid+1 || retry.Ts != 1_500_000 || retry.Dur != 1_000_000 {
+		t.Errorf("unexpected retry span: %+v", retry)
+	}
+
+	begin, end := findEvent(trace, "slot wait", "b"), findEvent(trace, "slot wait", "e")
+	if begin == nil || end == nil || begin.ID != "test#1" || end.ID != begin.ID || begin.Ts != 1_000_000 || end.Ts != 1_100_000 {
+		t.Errorf("unexpected slot wait: %+v, %+v", begin, end)
+	}
+	for i := 1; i < len(trace.Events); i++ {
+		if prev, e := trace.Events[i-1], trace.Events[i]; prev.Ph != "M" && e.Ts < prev.Ts {
+			t.Fatalf("events out of order at %d", i)
+		}
+	}
+}
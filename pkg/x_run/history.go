@@ -0,0 +1,99 @@
+package x_run
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// ---------- History ----------
+
+// Query selects runs from the history. Zero fields match every run.
+type Query struct {
+	Task   string    // Glob that a task of the run must match, e.g. "test:*"
+	Status Status    // Overall status of the run
+	Since  time.Time // Only runs created at or after this time
+	Limit  int       // Max number of runs, newest first; 0 for no limit
+}
+
+// Match reports whether a run matches the query, ignoring Limit.
+func (q Query) Match(s *State) bool {
+	if q.Status != "" && s.Status != q.Status {
+		return false
+	}
+	if !q.Since.IsZero() && s.Created.Before(q.Since) {
+		return false
+	}
+	if q.Task == "" {
+		return true
+	}
+	for _, ts := range s.Tasks {
+		if ok, _ := path.Match(q.Task, ts.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the runs in dir matching q, newest first.
+func Find(dir string, q Query) ([]*State, error) {
+	if _, err := path.Match(q.Task, ""); err != nil {
+		return nil, fmt.Errorf("invalid task pattern %q: %w", q.Task, err)
+	}
+	states, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*State
+	for _, s := range states {
+		if q.Limit > 0 && len(found) == q.Limit {
+			break
+		}
+		if q.Match(s) {
+			found = append(found, s)
+		}
+	}
+	return found, nil
+}
+
+// ParseStatus validates a status given on the command line.
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(strings.ToLower(s)); st {
+	case "", StatusPending, StatusRunning, StatusSuccess, StatusFailed, StatusSkipped:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown status %q (want success, failed, running, pending or skipped)", s)
+	}
+}
+
+// ParseSince parses a point in time given as a duration before now ("90m",
+// "36h", "7d"), a date ("2006-01-02") or an RFC 3339 time.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want e.g. 24h, 7d, 2006-01-02 or an RFC 3339 time)", s)
+}
+
+// Duration returns the time from the start of the run to its last checkpoint.
+func (s *State) Duration() time.Duration {
+	if s.Created.IsZero() || s.Updated.Before(s.Created) {
+		return 0
+	}
+	return s.Updated.Sub(s.Created)
+}
@@ -0,0 +1,55 @@
+package x_run
+
+import (
+	"testing"
+	"time"
+)
+
+//
+// ---------- Unit Tests for History ----------
+
+// TestQueryMatch verifies the task, status and time filters.
+func TestQueryMatch(t *testing.T) {
+	now := time.Now()
+	s := &State{
+		Status:  StatusFailed,
+		Created: now.Add(-2 * time.Hour),
+		Tasks:   []*TaskState{{Name: "build"}, {Name: "test:unit"}},
+	}
+
+	for _, tc := range []struct {
+		q    Query
+		want bool
+	}{
+		{Query{}, true},
+		{Query{Task: "test:*"}, true},
+		{Query{Task: "lint"}, false},
+		{Query{Status: StatusFailed}, true},
+		{Query{Status: StatusSuccess}, false},
+		{Query{Since: now.Add(-3 * time.Hour)}, true},
+		{Query{Since: now.Add(-time.Hour)}, false},
+	} {
+		if got := tc.q.Match(s); got != tc.want {
+			t.Errorf("%+v.Match = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+}
+
+// TestParseSince verifies durations, days and dates.
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for input, want := range map[string]time.Time{
+		"90m":                  now.Add(-90 * time.Minute),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2025-03-01":           time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2025-03-01T08:00:00Z": time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC),
+	} {
+		got, err := ParseSince(input, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseSince("yesterday", now); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
@@ -0,0 +1,216 @@
+package x_run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_artifact"
+)
+
+// Every task of a run writes its raw output, stdout and stderr in the order
+// they are written, to <run dir>/<task>.log while it runs. A new attempt on
+// resume starts the log over; the results in the run store keep the output
+// of earlier attempts. Logs are plain files so that another jt process can
+// follow a run in progress by polling them, for as long as the process
+// executing the run holds its run lock.
+
+//
+// ---------- Data Structures ----------
+
+// LogOptions select the logs read by Logs.
+type LogOptions struct {
+	Tasks  []string      // Tasks whose logs are read; empty for every task of the run
+	Tail   int           // Start with the last Tail lines of every log; 0 for all
+	Follow bool          // Keep reading until no process executes the run
+	Poll   time.Duration // How often logs and the run lock are polled when following
+}
+
+// logReader reads the lines appended to the log of one task.
+type logReader struct {
+	task    string // Task name
+	path    string // Path of the log file
+	offset  int64  // Bytes read so far
+	partial []byte // Start of a line not yet terminated
+}
+
+//
+// ---------- Public Functions ----------
+
+// LogPath returns the path of the log of a task in a run.
+func LogPath(dir, id, task string) string {
+	return filepath.Join(RunDir(dir, id), x_artifact.DirName(task)+".log")
+}
+
+// Logs calls fn with the lines of the logs of a run, task by task in run
+// order. With opts.Follow it then polls the logs and calls fn with every
+// new line, until no process executes the run any more, because it finished
+// or its process died, or ctx is cancelled.
+func Logs(ctx context.Context, dir, id string, opts LogOptions, fn func(task, line string)) error {
+	state, err := Load(dir, id)
+	if err != nil {
+		return err
+	}
+
+	tasks := opts.Tasks
+	if len(tasks) == 0 {
+		for _, ts := range state.Tasks {
+			tasks = append(tasks, ts.Name)
+		}
+	}
+	var readers []*logReader
+	for _, task := range tasks {
+		if state.Task(task) == nil {
+			return fmt.Errorf("task %q is not part of run %s", task, id)
+		}
+		r := &logReader{task: task, path: LogPath(dir, id, task)}
+		if err := r.tail(opts.Tail, fn); err != nil {
+			return err
+		}
+		readers = append(readers, r)
+	}
+
+	poll := opts.Poll
+	if poll <= 0 {
+		poll = 250 * time.Millisecond
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for active := opts.Follow && Active(dir, id); active; {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// Check first so that output written before the run finished is read
+		active = Active(dir, id)
+		for _, r := range readers {
+			if err := r.read(fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, r := range readers {
+		if err := r.read(fn); err != nil {
+			return err
+		}
+		r.flush(fn)
+	}
+	return nil
+}
+
+//
+// ---------- Helper Functions ----------
+
+// tail calls fn with the last n complete lines of the log, or all of them
+// if n is 0, and continues reading from its end.
+func (r *logReader) tail(n int, fn func(task, line string)) error {
+	f, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	if n > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		if r.offset, err = tailOffset(f, info.Size(), n); err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+	}
+	return r.readFrom(f, fn)
+}
+
+// read calls fn with the complete lines appended to the log since the
+// last read. A log that shrank was started over by a new attempt.
+func (r *logReader) read(fn func(task, line string)) error {
+	f, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	if info.Size() < r.offset {
+		r.offset, r.partial = 0, nil
+	}
+	return r.readFrom(f, fn)
+}
+
+// readFrom reads f from the offset of the reader to its end.
+func (r *logReader) readFrom(f *os.File, fn func(task, line string)) error {
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	r.offset += int64(len(data))
+
+	r.partial = append(r.partial, data...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		fn(r.task, string(bytes.TrimSuffix(r.partial[:i], []byte("\r"))))
+		r.partial = r.partial[i+1:]
+	}
+	return nil
+}
+
+// flush calls fn with a last line without a newline.
+func (r *logReader) flush(fn func(task, line string)) {
+	if len(r.partial) > 0 {
+		fn(r.task, string(bytes.TrimSuffix(r.partial, []byte("\r"))))
+		r.partial = nil
+	}
+}
+
+// tailOffset returns the offset of the start of the last n lines of f,
+// which has size bytes, reading backwards from its end. A last line
+// without a newline counts as a line.
+func tailOffset(f *os.File, size int64, n int) (int64, error) {
+	const block = 64 * 1024
+	buf := make([]byte, block)
+
+	end := size
+	lines := 0
+	for end > 0 {
+		start := max(end-block, 0)
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			if lines++; lines == n {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
@@ -0,0 +1,176 @@
+package x_run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_event"
+	"github.com/rskv-p/jtask/pkg/x_store"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Helpers ----------
+
+// collectLines returns a callback for Logs and the lines it received, as
+// "task: line".
+func collectLines() (func(task, line string), *[]string) {
+	var lines []string
+	return func(task, line string) { lines = append(lines, task+": "+line) }, &lines
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestLogs verifies that task logs are written and read with --tail.
+func TestLogs(t *testing.T) {
+	dir := t.TempDir()
+	collection := &x_task.TaskCollection{
+		Name: "test",
+		Data: []*x_task.Task{
+			{Name: "build", Exec: []string{"sh", "-c", "echo one; echo two >&2; echo three"}},
+			{Name: "ci/test", Exec: []string{"sh", "-c", "printf 'ok\\r\\nlast'"}, DependsOn: []string{"build"}},
+		},
+	}
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	state, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(LogPath(runner.RunsDir, state.ID, "ci/test"))
+	if err != nil || string(data) != "ok\r\nlast" {
+		t.Fatalf("unexpected raw log %q, %v", data, err)
+	}
+
+	fn, lines := collectLines()
+	if err := Logs(context.Background(), runner.RunsDir, state.ID, LogOptions{}, fn); err != nil {
+		t.Fatalf("Logs returned error: %v", err)
+	}
+	if got := strings.Join(*lines, "|"); got != "build: one|build: two|build: three|ci/test: ok|ci/test: last" {
+		t.Errorf("unexpected lines %q", got)
+	}
+
+	fn, lines = collectLines()
+	opts := LogOptions{Tasks: []string{"build"}, Tail: 2}
+	if err := Logs(context.Background(), runner.RunsDir, state.ID, opts, fn); err != nil {
+		t.Fatalf("Logs returned error: %v", err)
+	}
+	if got := strings.Join(*lines, "|"); got != "build: two|build: three" {
+		t.Errorf("unexpected tail %q", got)
+	}
+
+	if err := Logs(context.Background(), runner.RunsDir, state.ID, LogOptions{Tasks: []string{"nope"}}, fn); err == nil {
+		t.Errorf("expected an error for a task outside the run")
+	}
+}
+
+// TestLogsFollow verifies that following a run reads its output until the
+// run finishes.
+func TestLogsFollow(t *testing.T) {
+	dir := t.TempDir()
+	collection := &x_task.TaskCollection{
+		Name: "test",
+		Data: []*x_task.Task{
+			{Name: "slow", Exec: []string{"sh", "-c", "echo start; sleep 0.3; echo end"}},
+		},
+	}
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+
+	started := make(chan string, 1)
+	runner.Events = x_event.Func(func(e x_event.Event) {
+		if e.Head().Type == x_event.TypeTaskStarted {
+			started <- e.Head().Run
+		}
+	})
+	done := make(chan error, 1)
+	go func() {
+		_, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data)
+		done <- err
+	}()
+
+	fn, lines := collectLines()
+	opts := LogOptions{Follow: true, Poll: 20 * time.Millisecond}
+	if err := Logs(context.Background(), runner.RunsDir, <-started, opts, fn); err != nil {
+		t.Fatalf("Logs returned error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if got := strings.Join(*lines, "|"); got != "slow: start|slow: end" {
+		t.Errorf("unexpected followed lines %q", got)
+	}
+}
+
+// TestLogsFollowInterrupted verifies that following a run left running by
+// a process that died stops once the logs are read.
+func TestLogsFollowInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	state := &State{
+		ID:      NewID(),
+		Status:  StatusRunning,
+		Created: time.Now(),
+		Tasks:   []*TaskState{{Name: "build", Status: StatusRunning}},
+		store:   x_store.New(dir),
+	}
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+	path := LogPath(dir, state.ID, "build")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if Active(dir, state.ID) {
+		t.Fatal("expected no process to execute the run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	fn, lines := collectLines()
+	if err := Logs(ctx, dir, state.ID, LogOptions{Follow: true, Poll: 20 * time.Millisecond}, fn); err != nil {
+		t.Fatalf("Logs returned error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected following to stop without an owner of the run")
+	}
+	if got := strings.Join(*lines, "|"); got != "build: partial" {
+		t.Errorf("unexpected lines %q", got)
+	}
+}
+
+// TestTailOffset verifies finding the start of the last lines of a file.
+func TestTailOffset(t *testing.T) {
+	cases := []struct {
+		content string
+		n       int
+		want    string
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n"},
+		{"a\nb\nc", 2, "b\nc"},
+		{"a\nb\n", 5, "a\nb\n"},
+		{"", 1, ""},
+		{strings.Repeat("x\n", 50000) + "y\n", 2, "x\ny\n"},
+	}
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "log")
+		if err := os.WriteFile(path, []byte(c.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset, err := tailOffset(f, int64(len(c.content)), c.n)
+		f.Close()
+		if err != nil || c.content[offset:] != c.want {
+			t.Errorf("tailOffset(%q, %d) = %q, %v; want %q", c.content[max(len(c.content)-8, 0):], c.n, c.content[offset:], err, c.want)
+		}
+	}
+}
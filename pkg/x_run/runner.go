@@ -0,0 +1,500 @@
+package x_run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_artifact"
+	"github.com/rskv-p/jtask/pkg/x_baseline"
+	"github.com/rskv-p/jtask/pkg/x_event"
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_queue"
+	"github.com/rskv-p/jtask/pkg/x_store"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Errors ----------
+
+// ErrDefinitionChanged is returned by Resume when tasks changed since the run started.
+var ErrDefinitionChanged = errors.New("task definition changed since the run started")
+
+//
+// ---------- Runner ----------
+
+// Runner executes tasks as a run and checkpoints its state after every step.
+type Runner struct {
+	RunsDir   string            // Directory of the run store
+	LockDir   string            // Directory holding task lock files
+	Limiter   *x_queue.Limiter  // Bounds the number of concurrently running tasks
+	Retention x_store.Retention // Runs kept in the store, pruned after every run
+	Events    x_event.Sink      // Receives the lifecycle events of runs, if set
+	Baseline  x_baseline.Policy // Flags tasks slower than their duration baseline; zero disables baselines
+}
+
+// New creates a runner. A nil limiter runs one task at a time.
+func New(runsDir, lockDir string, limiter *x_queue.Limiter) *Runner {
+	if limiter == nil {
+		limiter = x_queue.NewLimiter(1)
+	}
+	return &Runner{RunsDir: runsDir, LockDir: lockDir, Limiter: limiter}
+}
+
+// Start creates a new run for tasks, which must be ordered by dependencies
+// (see x_task.TaskCollection.Resolve), and executes it.
+// Task failures are recorded in the returned state, not returned as errors.
+// Cancelling ctx kills running tasks and fails the ones not started yet.
+func (r *Runner) Start(ctx context.Context, collection *x_task.TaskCollection, tasksFile string, tasks []*x_task.Task) (*State, error) {
+	store := x_store.New(r.RunsDir)
+
+	state := &State{
+		ID:         NewID(),
+		TasksFile:  tasksFile,
+		Collection: collection.Name,
+		User:       currentUser(),
+		Status:     StatusRunning,
+		Created:    time.Now(),
+		store:      store,
+	}
+	for _, t := range tasks {
+		state.Tasks = append(state.Tasks, &TaskState{
+			Name:      t.Name,
+			Hash:      t.Hash(),
+			DependsOn: t.DependsOn,
+			Status:    StatusPending,
+		})
+	}
+
+	x_log.Info().
+		Str("run", state.ID).
+		Int("tasks", len(tasks)).
+		Msg("starting run")
+
+	lock, err := r.lockRun(state.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if err := state.Save(); err != nil {
+		return nil, err
+	}
+	return state, r.execute(ctx, state, tasks, false)
+}
+
+// Resume continues a run from its checkpoint. Tasks that already succeeded
+// are skipped; all others are run again. It fails with ErrDefinitionChanged
+// if any task of the run was changed or removed from collection.
+func (r *Runner) Resume(ctx context.Context, state *State, collection *x_task.TaskCollection) error {
+	var remaining []*x_task.Task
+	for _, ts := range state.Tasks {
+		t := collection.Find(ts.Name)
+		if t == nil {
+			return fmt.Errorf("%w: task %q was removed", ErrDefinitionChanged, ts.Name)
+		}
+		if t.Hash() != ts.Hash {
+			return fmt.Errorf("%w: task %q", ErrDefinitionChanged, ts.Name)
+		}
+		if ts.Status != StatusSuccess {
+			remaining = append(remaining, t)
+		}
+	}
+
+	x_log.Info().
+		Str("run", state.ID).
+		Int("completed", len(state.Tasks)-len(remaining)).
+		Int("remaining", len(remaining)).
+		Msg("resuming run")
+
+	lock, err := r.lockRun(state.ID)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	store := x_store.New(r.RunsDir)
+	state.store = store
+	if err := state.update(func() { state.Status = StatusRunning }); err != nil {
+		return err
+	}
+	return r.execute(ctx, state, remaining, true)
+}
+
+// lockRun takes the run lock, which marks the run as executed by this
+// process until it is released. It fails if another process executes it.
+func (r *Runner) lockRun(id string) (*x_lock.Lock, error) {
+	lock, err := x_lock.Acquire(RunDir(r.RunsDir, id), runLockName, x_lock.PolicyFail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock run %s: %w", id, err)
+	}
+	return lock, nil
+}
+
+//
+// ---------- Execution ----------
+
+// execute runs tasks through the scheduler and records their outcomes.
+func (r *Runner) execute(ctx context.Context, state *State, tasks []*x_task.Task, resumed bool) error {
+	if r.Events != nil {
+		started := &x_event.RunStarted{
+			Header:     x_event.NewHeader(x_event.TypeRunStarted, state.ID),
+			Collection: state.Collection,
+			TasksFile:  state.TasksFile,
+			User:       state.User,
+			Resumed:    resumed,
+		}
+		for _, t := range tasks {
+			started.Tasks = append(started.Tasks, t.Name)
+		}
+		r.Events.Emit(started)
+		for _, t := range tasks {
+			r.Events.Emit(&x_event.TaskQueued{
+				Header:    x_event.NewHeader(x_event.TypeTaskQueued, state.ID),
+				Task:      t.Name,
+				DependsOn: t.DependsOn,
+			})
+		}
+	}
+
+	results := x_queue.RunSlots(tasks, r.Limiter, func(t *x_task.Task, slot x_queue.Slot) error {
+		return r.runTask(ctx, state, t, slot)
+	})
+
+	// Tasks never started because a dependency failed are marked skipped,
+	// in dependency order so that events and checkpoints are deterministic
+	var saveErr error
+	for _, t := range tasks {
+		if err := results[t.Name]; errors.Is(err, x_queue.ErrDependencyFailed) {
+			ts := state.Task(t.Name)
+			if err := state.updateTask(ts, func() {
+				ts.Status = StatusSkipped
+				ts.Error = err.Error()
+			}); err != nil {
+				saveErr = err
+			}
+			r.taskFinished(state, ts)
+		}
+	}
+
+	r.checkBaselines(state, tasks)
+
+	// Tasks skipped because of a held lock ran elsewhere and do not fail the run;
+	// tasks skipped because of a failed dependency carry an error and do
+	if err := state.update(func() {
+		state.Status = StatusSuccess
+		for _, ts := range state.Tasks {
+			if ts.Status == StatusFailed || ts.Status == StatusSkipped && ts.Error != "" {
+				state.Status = StatusFailed
+			}
+		}
+	}); err != nil {
+		saveErr = err
+	}
+
+	x_log.Info().
+		Str("run", state.ID).
+		Str("status", string(state.Status)).
+		Msg("run finished")
+
+	if r.Events != nil {
+		finished := &x_event.RunFinished{
+			Header:     x_event.NewHeader(x_event.TypeRunFinished, state.ID),
+			Status:     string(state.Status),
+			DurationMS: state.Duration().Milliseconds(),
+		}
+		for _, ts := range state.Tasks {
+			switch ts.Status {
+			case StatusSuccess:
+				finished.Succeeded++
+			case StatusFailed:
+				finished.Failed++
+			case StatusSkipped:
+				finished.Skipped++
+			}
+		}
+		finished.Regressions = len(state.Regressions())
+		r.Events.Emit(finished)
+	}
+
+	if r.Retention != (x_store.Retention{}) {
+		if _, err := prune(state.store, r.Retention); err != nil {
+			x_log.Warn().
+				Err(err).
+				Str("dir", r.RunsDir).
+				Msg("failed to prune old runs")
+		}
+	}
+	return saveErr
+}
+
+// runTask takes the task lock, executes the task and checkpoints the outcome.
+func (r *Runner) runTask(ctx context.Context, state *State, t *x_task.Task, slot x_queue.Slot) error {
+	ts := state.Task(t.Name)
+	var output, resultID string
+	attempt := &Attempt{Task: t.Name, Slot: slot.Index, Ready: slot.Ready, Slotted: time.Now()}
+
+	// fail records a task failure and returns it to the scheduler
+	fail := func(err error) error {
+		code := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		if saveErr := state.updateTask(ts, func() {
+			ts.Status = StatusFailed
+			ts.Error = err.Error()
+			ts.ExitCode = code
+			ts.Output = output
+			ts.Result = resultID
+			ts.Finished = time.Now()
+		}); saveErr != nil {
+			x_log.Error().Err(saveErr).Str("run", state.ID).Msg("failed to save run state")
+		}
+		r.taskFinished(state, ts)
+		return err
+	}
+
+	// Do not start new tasks once the run is cancelled
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+
+	policy, err := x_lock.ParsePolicy(t.Concurrency)
+	if err != nil {
+		return fail(err)
+	}
+	lock, err := x_lock.Acquire(r.LockDir, x_lock.TaskLockName(t.Name), policy)
+	if errors.Is(err, x_lock.ErrLocked) && policy == x_lock.PolicySkip {
+		// Another process runs this task; that is not a failure
+		x_log.Warn().
+			Str("task", t.Name).
+			Msg("task skipped, lock is held")
+		err := state.updateTask(ts, func() { ts.Status = StatusSkipped })
+		r.taskFinished(state, ts)
+		return err
+	}
+	if err != nil {
+		return fail(err)
+	}
+	defer lock.Release()
+
+	// A task with an earlier attempt failed before and is started again
+	if r.Events != nil && ts.Attempts > 0 {
+		r.Events.Emit(&x_event.TaskRetry{
+			Header:        x_event.NewHeader(x_event.TypeTaskRetry, state.ID),
+			Task:          t.Name,
+			Attempt:       ts.Attempts + 1,
+			PreviousError: ts.Error,
+		})
+	}
+
+	attempt.Locked = time.Now()
+	if err := state.updateTask(ts, func() {
+		ts.Status = StatusRunning
+		ts.Error = ""
+		ts.ExitCode = 0
+		ts.Attempts++
+		ts.Started = time.Now()
+	}); err != nil {
+		return err
+	}
+	attempt.Number = ts.Attempts
+	defer r.saveAttempt(state, ts, attempt)
+
+	var onLine func(stream, line string)
+	if r.Events != nil {
+		r.Events.Emit(&x_event.TaskStarted{
+			Header:  x_event.NewHeader(x_event.TypeTaskStarted, state.ID),
+			Task:    t.Name,
+			Attempt: ts.Attempts,
+			Argv:    t.Command(),
+		})
+		onLine = func(stream, line string) {
+			r.Events.Emit(&x_event.OutputLine{
+				Header: x_event.NewHeader(x_event.TypeOutputLine, state.ID),
+				Task:   t.Name,
+				Stream: stream,
+				Line:   line,
+			})
+		}
+	}
+
+	// A nil *os.File must not become a non-nil io.Writer
+	var out io.Writer
+	if logFile := r.openLog(state, t); logFile != nil {
+		defer logFile.Close()
+		out = logFile
+	}
+	result, err := x_task.ExecuteTaskOutput(ctx, t, out, onLine)
+	r.collectArtifacts(state, t)
+	if result != nil {
+		// Keep the result of every attempt in the run store
+		if putErr := state.store.Put(state.ID, resultKey+result.ID, result); putErr != nil {
+			x_log.Error().Err(putErr).Str("run", state.ID).Msg("failed to save task result")
+		} else {
+			resultID = result.ID
+		}
+	}
+	if err != nil {
+		if result != nil {
+			output = result.Output
+		}
+		return fail(err)
+	}
+
+	// Print task output if configured
+	if t.IsPrintOutput {
+		x_log.Info().
+			Str("task", t.Name).
+			Str("output", result.Output).
+			Msg("task output")
+	}
+
+	// Only registered output is kept in the run state; the result keeps all of it
+	if t.IsPrintOutput {
+		output = result.Output
+	}
+	err = state.updateTask(ts, func() {
+		ts.Status = StatusSuccess
+		ts.Output = output
+		ts.Result = resultID
+		ts.Finished = time.Now()
+	})
+	r.taskFinished(state, ts)
+	return err
+}
+
+// saveAttempt records how an execution of a task was scheduled, once the
+// task finished.
+func (r *Runner) saveAttempt(state *State, ts *TaskState, attempt *Attempt) {
+	attempt.Finished = ts.Finished
+	attempt.Status = ts.Status
+	attempt.Result = ts.Result
+	key := fmt.Sprintf("%s%s/%d", attemptKey, ts.Name, attempt.Number)
+	if err := state.store.Put(state.ID, key, attempt); err != nil {
+		x_log.Error().Err(err).Str("run", state.ID).Msg("failed to save task attempt")
+	}
+}
+
+// checkBaselines compares the duration of every task of tasks that
+// succeeded with its baseline, records regressions in the state and saves
+// the baselines rolled forward to the run store.
+func (r *Runner) checkBaselines(state *State, tasks []*x_task.Task) {
+	if !r.Baseline.Enabled() {
+		return
+	}
+	latest, err := baselines(state.store)
+	if err != nil {
+		x_log.Warn().Err(err).Str("run", state.ID).Msg("failed to read duration baselines")
+		return
+	}
+
+	regressions := make(map[*TaskState]*x_baseline.Regression)
+	for _, t := range tasks {
+		ts := state.Task(t.Name)
+		if ts.Status != StatusSuccess || ts.Duration() <= 0 {
+			continue
+		}
+		b := latest[t.Name]
+		regressions[ts] = r.Baseline.Check(b, ts.Duration())
+		if regression := regressions[ts]; regression != nil {
+			x_log.Warn().
+				Str("run", state.ID).
+				Str("task", t.Name).
+				Str("regression", regression.String()).
+				Msg("task ran slower than its baseline")
+		}
+		if err := state.store.Put(state.ID, baselineKey+t.Name, b.Add(ts.Duration(), r.Baseline.Window)); err != nil {
+			x_log.Error().Err(err).Str("run", state.ID).Msg("failed to save duration baseline")
+		}
+	}
+
+	for ts, regression := range regressions {
+		if regression == nil && ts.Regression == nil {
+			continue
+		}
+		if err := state.updateTask(ts, func() { ts.Regression = regression }); err != nil {
+			x_log.Error().Err(err).Str("run", state.ID).Msg("failed to save run state")
+		}
+	}
+}
+
+// openLog starts the log of a task over for a new attempt. It returns nil,
+// and the task runs without a log, if the file cannot be created.
+func (r *Runner) openLog(state *State, t *x_task.Task) *os.File {
+	path := LogPath(r.RunsDir, state.ID, t.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		x_log.Warn().Err(err).Str("run", state.ID).Msg("failed to create run dir")
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		x_log.Warn().Err(err).Str("run", state.ID).Str("task", t.Name).Msg("failed to create task log")
+		return nil
+	}
+	return f
+}
+
+// collectArtifacts keeps the artifacts of a task, whether it succeeded or
+// failed, and records their manifest. Errors are logged and do not fail
+// the task.
+func (r *Runner) collectArtifacts(state *State, t *x_task.Task) {
+	if len(t.Artifacts) == 0 {
+		return
+	}
+	files, err := x_artifact.Collect(t.Artifacts, ".", ArtifactDir(r.RunsDir, state.ID, t.Name), r.RunsDir)
+	if err != nil {
+		x_log.Warn().
+			Err(err).
+			Str("run", state.ID).
+			Str("task", t.Name).
+			Msg("failed to collect artifacts")
+	}
+	if files == nil {
+		files = []x_artifact.File{}
+	}
+	if err := state.store.Put(state.ID, artifactKey+t.Name, files); err != nil {
+		x_log.Error().Err(err).Str("run", state.ID).Msg("failed to save artifact manifest")
+	}
+}
+
+// taskFinished emits the task_finished event of a task.
+func (r *Runner) taskFinished(state *State, ts *TaskState) {
+	if r.Events == nil {
+		return
+	}
+	e := &x_event.TaskFinished{
+		Header:     x_event.NewHeader(x_event.TypeTaskFinished, state.ID),
+		Task:       ts.Name,
+		Status:     string(ts.Status),
+		ExitCode:   ts.ExitCode,
+		Error:      ts.Error,
+		DurationMS: ts.Duration().Milliseconds(),
+		Attempt:    ts.Attempts,
+		Result:     ts.Result,
+	}
+	// A skipped task did not run now, whatever earlier attempts recorded
+	if ts.Status == StatusSkipped {
+		e.ExitCode, e.DurationMS, e.Result = -1, 0, ""
+	}
+	r.Events.Emit(e)
+}
+
+//
+// ---------- Helper Functions ----------
+
+// currentUser returns the name of the user running jt.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
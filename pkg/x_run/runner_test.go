@@ -0,0 +1,430 @@
+package x_run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rskv-p/jtask/pkg/x_baseline"
+	"github.com/rskv-p/jtask/pkg/x_event"
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_queue"
+	"github.com/rskv-p/jtask/pkg/x_store"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Helpers ----------
+
+// newCollection builds a collection where "check" fails until marker exists
+// and "prepare" appends a line to counter every time it runs.
+func newCollection(counter, marker string) *x_task.TaskCollection {
+	return &x_task.TaskCollection{
+		Name: "test",
+		Data: []*x_task.Task{
+			{Name: "prepare", Exec: []string{"sh", "-c", "echo run >> " + counter}},
+			{Name: "check", Exec: []string{"test", "-f", marker}, DependsOn: []string{"prepare"}},
+			{Name: "publish", Exec: []string{"echo", "published"}, IsPrintOutput: true, DependsOn: []string{"check"}},
+		},
+	}
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestStartAndResume verifies that a failed run is checkpointed and resumed
+// without re-running completed tasks.
+func TestStartAndResume(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "counter")
+	marker := filepath.Join(dir, "marker")
+	collection := newCollection(counter, marker)
+
+	tasks, err := collection.Resolve([]string{"publish"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	state, err := runner.Start(context.Background(), collection, "tasks.json", tasks)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if state.Status != StatusFailed {
+		t.Fatalf("expected failed run, got %s", state.Status)
+	}
+	if got := state.Task("check"); got.Status != StatusFailed || got.ExitCode != 1 || got.Attempts != 1 {
+		t.Errorf("expected check to fail with exit code 1, got %+v", got)
+	}
+	if got := state.Task("publish").Status; got != StatusSkipped {
+		t.Errorf("expected publish to be skipped, got %s", got)
+	}
+
+	// The checkpoint on disk matches the in-memory state
+	loaded, err := Load(runner.RunsDir, state.ID)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if loaded.Task("prepare").Status != StatusSuccess || loaded.TasksFile != "tasks.json" {
+		t.Errorf("unexpected loaded state: %+v", loaded)
+	}
+
+	// Fix the failure and resume
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Resume(context.Background(), loaded, collection); err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+
+	if loaded.Status != StatusSuccess {
+		t.Errorf("expected resumed run to succeed, got %s", loaded.Status)
+	}
+	if out := loaded.Task("publish").Output; out != "published\n" {
+		t.Errorf("expected registered output, got %q", out)
+	}
+	if check := loaded.Task("check"); check.ExitCode != 0 || check.Attempts != 2 {
+		t.Errorf("expected check to succeed on its second attempt, got %+v", check)
+	}
+
+	// The run record keeps only the task order; every task has its own record
+	var record struct{ Tasks []string }
+	if err := x_store.New(runner.RunsDir).Get(state.ID, stateKey, &record); err != nil || !slices.Equal(record.Tasks, []string{"prepare", "check", "publish"}) {
+		t.Errorf("unexpected run record: %+v, %v", record, err)
+	}
+	reloaded, err := Load(runner.RunsDir, state.ID)
+	if err != nil || reloaded.Status != StatusSuccess || len(reloaded.Tasks) != 3 || reloaded.Tasks[2].Output != "published\n" {
+		t.Errorf("unexpected reloaded state: %+v, %v", reloaded, err)
+	}
+	if reloaded.Updated.Before(reloaded.Task("publish").Finished) {
+		t.Errorf("expected the run updated at its last checkpoint, got %s", reloaded.Updated)
+	}
+
+	// prepare ran only once
+	data, _ := os.ReadFile(counter)
+	if n := strings.Count(string(data), "run"); n != 1 {
+		t.Errorf("expected prepare to run once, ran %d times", n)
+	}
+
+	// Every execution, including the failed attempt, is kept in the store
+	results, err := Results(runner.RunsDir, state.ID)
+	if err != nil || len(results) != 4 {
+		t.Fatalf("Results = %d results, %v", len(results), err)
+	}
+	if first := results[1]; first.Name != "check" || first.ExitCode != 1 || first.Error == "" {
+		t.Errorf("expected the failed check attempt, got %+v", first)
+	}
+	if last := results[3]; last.Name != "publish" || last.ID != loaded.Task("publish").Result {
+		t.Errorf("expected the publish result to be referenced, got %+v", last)
+	}
+}
+
+// TestEvents verifies the lifecycle events of a failed run and its resume.
+func TestEvents(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+	collection := newCollection(filepath.Join(dir, "counter"), marker)
+	tasks, _ := collection.Resolve([]string{"publish"})
+
+	var mu sync.Mutex
+	var types []string
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	runner.Events = x_event.Func(func(e x_event.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		h := e.Head()
+		name := string(h.Type)
+		switch e := e.(type) {
+		case *x_event.TaskStarted:
+			name += ":" + e.Task
+		case *x_event.OutputLine:
+			name += ":" + e.Line
+		case *x_event.TaskFinished:
+			name += ":" + e.Task + ":" + e.Status
+		case *x_event.TaskRetry:
+			name += ":" + e.Task
+		}
+		types = append(types, name)
+	})
+
+	state, err := runner.Start(context.Background(), collection, "tasks.json", tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"run_started", "task_queued", "task_queued", "task_queued",
+		"task_started:prepare", "task_finished:prepare:success",
+		"task_started:check", "task_finished:check:failed",
+		"task_finished:publish:skipped",
+		"run_finished",
+	}
+	if strings.Join(types, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected events:\n got %v\nwant %v", types, want)
+	}
+
+	types = nil
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Resume(context.Background(), state, collection); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"run_started", "task_queued", "task_queued",
+		"task_retry:check", "task_started:check", "task_finished:check:success",
+		"task_started:publish", "output_line:published", "task_finished:publish:success",
+		"run_finished",
+	}
+	if strings.Join(types, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected events on resume:\n got %v\nwant %v", types, want)
+	}
+}
+
+// TestEventsSkippedOrder verifies that tasks skipped because a dependency
+// failed finish in dependency order.
+func TestEventsSkippedOrder(t *testing.T) {
+	dir := t.TempDir()
+	collection := &x_task.TaskCollection{Name: "test", Data: []*x_task.Task{{Name: "base", Exec: []string{"false"}}}}
+	var want []string
+	for i := range 8 {
+		name := fmt.Sprintf("dep%d", i)
+		collection.Data = append(collection.Data, &x_task.Task{Name: name, Exec: []string{"true"}, DependsOn: []string{"base"}})
+		want = append(want, name)
+	}
+
+	var skipped []string
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	runner.Events = x_event.Func(func(e x_event.Event) {
+		if e, ok := e.(*x_event.TaskFinished); ok && e.Status == string(StatusSkipped) {
+			skipped = append(skipped, e.Task)
+		}
+	})
+	if _, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(skipped, want) {
+		t.Errorf("unexpected order of skipped tasks: %v", skipped)
+	}
+}
+
+// TestArtifacts verifies that artifacts are kept for failed tasks too and
+// removed when their run is pruned.
+func TestArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	collection := &x_task.TaskCollection{
+		Name: "test",
+		Data: []*x_task.Task{
+			{Name: "build", Exec: []string{"sh", "-c", "mkdir -p dist && echo bin > dist/app"}, Artifacts: []string{"dist/*"}},
+			{Name: "shot", Exec: []string{"sh", "-c", "echo png > shot.png; exit 1"}, Artifacts: []string{"*.png"}},
+		},
+	}
+
+	runner := New("runs", "lock", nil)
+	state, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	artifacts, err := Artifacts(runner.RunsDir, state.ID)
+	if err != nil {
+		t.Fatalf("Artifacts returned error: %v", err)
+	}
+	if len(artifacts["build"]) != 1 || artifacts["build"][0].Path != "dist/app" || artifacts["build"][0].Size != 4 {
+		t.Errorf("unexpected build artifacts: %+v", artifacts["build"])
+	}
+	if len(artifacts["shot"]) != 1 || artifacts["shot"][0].Path != "shot.png" {
+		t.Errorf("unexpected artifacts of the failed task: %+v", artifacts["shot"])
+	}
+	if _, err := os.Stat(filepath.Join(ArtifactDir(runner.RunsDir, state.ID, "build"), "dist", "app")); err != nil {
+		t.Errorf("expected the artifact in the run dir: %v", err)
+	}
+
+	// A newer run pushes the first one out of the retention
+	if _, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Prune(runner.RunsDir, x_store.Retention{MaxRuns: 1}); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if _, err := os.Stat(RunDir(runner.RunsDir, state.ID)); !os.IsNotExist(err) {
+		t.Errorf("expected the run dir to be removed, got %v", err)
+	}
+}
+
+// TestPruneActive verifies that a run another process still executes is not
+// pruned, whatever the retention.
+func TestPruneActive(t *testing.T) {
+	dir := t.TempDir()
+	collection := newCollection(filepath.Join(dir, "counter"), filepath.Join(dir, "marker"))
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	first, _ := runner.Start(context.Background(), collection, "tasks.json", collection.Data[:1])
+	if _, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := x_lock.Acquire(RunDir(runner.RunsDir, first.ID), runLockName, x_lock.PolicyFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := Prune(runner.RunsDir, x_store.Retention{MaxRuns: 1})
+	if err != nil || len(dropped) != 0 {
+		t.Fatalf("expected the active run to be kept, dropped %v, %v", dropped, err)
+	}
+	if _, err := Load(runner.RunsDir, first.ID); err != nil {
+		t.Errorf("expected the active run in the store: %v", err)
+	}
+	if _, err := os.Stat(lock.Path); err != nil {
+		t.Errorf("expected the run lock to be kept: %v", err)
+	}
+
+	lock.Release()
+	if dropped, err := Prune(runner.RunsDir, x_store.Retention{MaxRuns: 1}); err != nil || len(dropped) != 1 || dropped[0] != first.ID {
+		t.Errorf("expected the finished run to be dropped, got %v, %v", dropped, err)
+	}
+}
+
+// TestBaselines verifies that a task slower than the baseline of earlier
+// runs is flagged and that failures do not enter the baseline.
+func TestBaselines(t *testing.T) {
+	dir := t.TempDir()
+	delay := filepath.Join(dir, "delay")
+	collection := &x_task.TaskCollection{
+		Name: "test",
+		Data: []*x_task.Task{{Name: "bench", Exec: []string{"sh", "-c", "sleep $(cat " + delay + ")"}}},
+	}
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	runner.Baseline = x_baseline.Policy{Window: 5, MinSamples: 3, Factor: 2}
+
+	var finished *x_event.RunFinished
+	runner.Events = x_event.Func(func(e x_event.Event) {
+		if f, ok := e.(*x_event.RunFinished); ok {
+			finished = f
+		}
+	})
+	run := func(seconds string) *State {
+		t.Helper()
+		if err := os.WriteFile(delay, []byte(seconds), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		state, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data)
+		if err != nil {
+			t.Fatalf("Start returned error: %v", err)
+		}
+		return state
+	}
+
+	for range 3 {
+		if state := run("0.02"); state.Task("bench").Regression != nil {
+			t.Fatalf("unexpected regression while building the baseline: %+v", state.Task("bench").Regression)
+		}
+	}
+	run("not-a-number") // A failure is not part of the baseline
+
+	state := run("0.3")
+	r := state.Task("bench").Regression
+	if r == nil || r.Samples != 3 || r.Ratio < 2 {
+		t.Fatalf("expected a regression against 3 samples, got %+v", r)
+	}
+	if finished == nil || finished.Regressions != 1 {
+		t.Errorf("expected run_finished to count the regression, got %+v", finished)
+	}
+	if loaded, err := Load(runner.RunsDir, state.ID); err != nil || len(loaded.Regressions()) != 1 {
+		t.Errorf("expected the regression in the saved state, got %v", err)
+	}
+}
+
+// TestAttempts verifies that every execution records its slot and its
+// wait for one.
+func TestAttempts(t *testing.T) {
+	dir := t.TempDir()
+	collection := &x_task.TaskCollection{
+		Name: "test",
+		Data: []*x_task.Task{
+			{Name: "a", Exec: []string{"sleep", "0.2"}},
+			{Name: "b", Exec: []string{"sleep", "0.2"}},
+		},
+	}
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	runner.Limiter = x_queue.NewLimiter(1)
+	state, err := runner.Start(context.Background(), collection, "tasks.json", collection.Data)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	attempts, err := Attempts(runner.RunsDir, state.ID)
+	if err != nil {
+		t.Fatalf("Attempts returned error: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(attempts))
+	}
+	first, second := attempts[0], attempts[1]
+	if first.Slot != 0 || second.Slot != 0 || first.Number != 1 || second.Number != 1 {
+		t.Errorf("expected both tasks in slot 0, got %+v and %+v", first, second)
+	}
+	if !second.Ready.Before(first.Finished) || second.Slotted.Before(first.Finished) {
+		t.Errorf("expected %s to wait for the slot until %s finished, got %+v", second.Task, first.Task, second)
+	}
+	if second.Result != state.Task(second.Task).Result || second.Status != StatusSuccess || second.Finished.Before(second.Locked) {
+		t.Errorf("unexpected attempt: %+v", second)
+	}
+}
+
+// TestResumeDefinitionChanged verifies that changed tasks prevent a resume.
+func TestResumeDefinitionChanged(t *testing.T) {
+	dir := t.TempDir()
+	collection := newCollection(filepath.Join(dir, "counter"), filepath.Join(dir, "marker"))
+	tasks, _ := collection.Resolve([]string{"publish"})
+
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+	state, err := runner.Start(context.Background(), collection, "tasks.json", tasks)
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	collection.Find("check").Exec = []string{"true"}
+	if err := runner.Resume(context.Background(), state, collection); !errors.Is(err, ErrDefinitionChanged) {
+		t.Errorf("expected ErrDefinitionChanged, got %v", err)
+	}
+}
+
+// TestListLatest verifies that runs are listed newest first and that the
+// latest finished state of every task is found.
+func TestListLatest(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+	collection := newCollection(filepath.Join(dir, "counter"), marker)
+	tasks, _ := collection.Resolve([]string{"publish"})
+	runner := New(filepath.Join(dir, "runs"), filepath.Join(dir, "lock"), nil)
+
+	first, _ := runner.Start(context.Background(), collection, "tasks.json", tasks)
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := runner.Start(context.Background(), collection, "tasks.json", tasks[1:2])
+
+	runs, err := List(runner.RunsDir)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != second.ID || runs[1].ID != first.ID {
+		t.Fatalf("unexpected runs order: %v", runs)
+	}
+
+	latest := Latest(runs)
+	if latest["check"].Status != StatusSuccess || latest["publish"].Status != StatusSkipped {
+		t.Errorf("unexpected latest states: check=%s publish=%s", latest["check"].Status, latest["publish"].Status)
+	}
+
+	if runs, err := List(filepath.Join(dir, "missing")); err != nil || runs != nil {
+		t.Errorf("expected no runs for missing dir, got %v, %v", runs, err)
+	}
+}
@@ -0,0 +1,485 @@
+package x_run
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_artifact"
+	"github.com/rskv-p/jtask/pkg/x_baseline"
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_store"
+	"github.com/rskv-p/jtask/pkg/x_task"
+	"github.com/rskv-p/jtask/pkg/x_util"
+)
+
+//
+// ---------- Status ----------
+
+// Status is the lifecycle state of a run or of a task within a run.
+type Status string
+
+const (
+	StatusPending Status = "pending" // Not started yet
+	StatusRunning Status = "running" // In progress, or interrupted if no process holds the run lock
+	StatusSuccess Status = "success" // Finished successfully
+	StatusFailed  Status = "failed"  // Finished with an error
+	StatusSkipped Status = "skipped" // Not run: lock held elsewhere or a dependency failed
+)
+
+//
+// ---------- Data Structures ----------
+
+// TaskState records the progress and outputs of one task in a run.
+type TaskState struct {
+	Name       string                 `json:"name"`                 // Task name
+	Hash       string                 `json:"hash"`                 // Fingerprint of the task definition
+	DependsOn  []string               `json:"depends_on,omitempty"` // Dependencies of the task within the run
+	Status     Status                 `json:"status"`               // Current status
+	Output     string                 `json:"output,omitempty"`     // Registered output
+	Error      string                 `json:"error,omitempty"`      // Error message if the task failed
+	ExitCode   int                    `json:"exit_code,omitempty"`  // Exit code of the command, -1 if it did not exit
+	Result     string                 `json:"result,omitempty"`     // ID of the recorded x_task.Result of the last attempt
+	Attempts   int                    `json:"attempts,omitempty"`   // Times the task was started, across resumes
+	Started    time.Time              `json:"started"`              // When the task started
+	Finished   time.Time              `json:"finished"`             // When the task finished
+	Regression *x_baseline.Regression `json:"regression,omitempty"` // Set if the task ran slower than its baseline
+}
+
+// Attempt records how one execution of a task was scheduled: when it
+// waited for a concurrency slot and for its lock, and the slot it ran in.
+type Attempt struct {
+	Task     string    `json:"task"`             // Task name
+	Number   int       `json:"number"`           // Attempt number, from 1
+	Slot     int       `json:"slot"`             // Concurrency slot the task ran in, from 0
+	Ready    time.Time `json:"ready"`            // Dependencies done, waiting for a slot
+	Slotted  time.Time `json:"slotted"`          // Slot taken, waiting for the task lock
+	Locked   time.Time `json:"locked"`           // Lock taken or not needed, command starting
+	Finished time.Time `json:"finished"`         // When the attempt ended
+	Status   Status    `json:"status"`           // success or failed
+	Result   string    `json:"result,omitempty"` // ID of the recorded x_task.Result
+}
+
+// State is the checkpoint of a run, persisted after every task transition.
+type State struct {
+	ID         string       `json:"id"`         // Run ID
+	TasksFile  string       `json:"tasks_file"` // Tasks file the run was started from
+	Collection string       `json:"collection"` // Collection name
+	User       string       `json:"user"`       // User who started the run
+	Status     Status       `json:"status"`     // Overall status
+	Created    time.Time    `json:"created"`    // When the run was started
+	Updated    time.Time    `json:"updated"`    // Last checkpoint time
+	Tasks      []*TaskState `json:"tasks"`      // Tasks in dependency order
+
+	mu    sync.Mutex     // Guards the state while tasks run in parallel
+	store *x_store.Store // Store the state is saved to
+}
+
+// stateRecord is a State as kept in the store under stateKey. The tasks are
+// kept under taskKey, so that a checkpoint of one task does not write the
+// others again; the record only holds their order.
+type stateRecord struct {
+	*State
+	Tasks []string `json:"tasks"` // Task names in dependency order
+}
+
+// runLockName is the name of the lock held in the run directory by the
+// process executing the run. Its file records the PID and host of the owner.
+const runLockName = "run"
+
+// Keys of the records of a run in the store.
+const (
+	stateKey    = "state"      // The stateRecord, rewritten when the run changes
+	taskKey     = "task/"      // Prefix of the TaskState of every task, rewritten at every checkpoint
+	resultKey   = "result/"    // Prefix of the x_task.Result of every execution
+	artifactKey = "artifacts/" // Prefix of the artifact manifest of every task
+	baselineKey = "baseline/"  // Prefix of the duration baseline of every task, as of the run
+	attemptKey  = "attempt/"   // Prefix of the Attempt of every execution
+)
+
+//
+// ---------- Public Functions ----------
+
+// NewID returns a new run ID: a ULID, which sorts by creation time.
+func NewID() string {
+	return x_util.NewULID()
+}
+
+// Load reads the state of a run from the store in the runs directory.
+func Load(dir, id string) (*State, error) {
+	store := x_store.New(dir)
+	records, err := store.Records(id)
+	var s *State
+	if err == nil {
+		s, err = decodeState(store, records)
+	}
+	if err != nil {
+		x_log.Error().
+			Err(err).
+			Str("run", id).
+			Msg("failed to read run state")
+		return nil, fmt.Errorf("failed to read run state: %w", err)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("run %s not found", id)
+	}
+	return s, nil
+}
+
+// List loads every run in the runs directory, newest first.
+// A missing directory yields no runs; unreadable runs are skipped.
+func List(dir string) ([]*State, error) {
+	store := x_store.New(dir)
+	records, err := store.Scan(stateKey, taskKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	// Scan returns the records of a run next to each other
+	var states []*State
+	for len(records) > 0 {
+		n := 1
+		for n < len(records) && records[n].Run == records[0].Run {
+			n++
+		}
+		s, err := decodeState(store, records[:n])
+		if err != nil {
+			x_log.Warn().
+				Err(err).
+				Str("run", records[0].Run).
+				Msg("skipping unreadable run")
+		} else if s != nil {
+			states = append(states, s)
+		}
+		records = records[n:]
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Created.After(states[j].Created) })
+	return states, nil
+}
+
+// Results returns the recorded result of every task execution of a run,
+// including earlier attempts, in order of execution.
+func Results(dir, id string) ([]*x_task.Result, error) {
+	store := x_store.New(dir)
+	records, err := store.Records(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*x_task.Result
+	for _, r := range records {
+		if !strings.HasPrefix(r.Key, resultKey) {
+			continue
+		}
+		var result x_task.Result
+		if err := json.Unmarshal(r.Value, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse result %s of run %s: %w", r.Key, id, err)
+		}
+		results = append(results, &result)
+	}
+	return results, nil
+}
+
+// Attempts returns how every task execution of a run was scheduled, in
+// order of the start of the attempts.
+func Attempts(dir, id string) ([]*Attempt, error) {
+	store := x_store.New(dir)
+	records, err := store.Records(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []*Attempt
+	for _, r := range records {
+		if !strings.HasPrefix(r.Key, attemptKey) {
+			continue
+		}
+		var a Attempt
+		if err := json.Unmarshal(r.Value, &a); err != nil {
+			return nil, fmt.Errorf("failed to parse attempt %s of run %s: %w", r.Key, id, err)
+		}
+		attempts = append(attempts, &a)
+	}
+	sort.SliceStable(attempts, func(i, j int) bool { return attempts[i].Slotted.Before(attempts[j].Slotted) })
+	return attempts, nil
+}
+
+// AllResults returns the recorded results of every run in dir by run ID,
+// each in order of execution.
+func AllResults(dir string) (map[string][]*x_task.Result, error) {
+	store := x_store.New(dir)
+	records, err := store.Scan(resultKey)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]*x_task.Result)
+	for _, r := range records {
+		var result x_task.Result
+		if err := json.Unmarshal(r.Value, &result); err != nil {
+			x_log.Warn().
+				Err(err).
+				Str("run", r.Run).
+				Str("key", r.Key).
+				Msg("skipping unreadable result")
+			continue
+		}
+		results[r.Run] = append(results[r.Run], &result)
+	}
+	return results, nil
+}
+
+// Artifacts returns the artifact manifest of every task of a run that
+// declares artifacts, by task name.
+func Artifacts(dir, id string) (map[string][]x_artifact.File, error) {
+	store := x_store.New(dir)
+	records, err := store.Records(id)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make(map[string][]x_artifact.File)
+	for _, r := range records {
+		task, ok := strings.CutPrefix(r.Key, artifactKey)
+		if !ok {
+			continue
+		}
+		var files []x_artifact.File
+		if err := json.Unmarshal(r.Value, &files); err != nil {
+			return nil, fmt.Errorf("failed to parse artifacts of task %s in run %s: %w", task, id, err)
+		}
+		artifacts[task] = files
+	}
+	return artifacts, nil
+}
+
+// RunDir returns the directory holding the files of a run, such as its
+// artifacts. It is removed together with the run when the run is pruned.
+func RunDir(dir, id string) string {
+	return filepath.Join(dir, id)
+}
+
+// Active reports whether a process executes the run, which holds the run
+// lock in the run directory while it does. A run left running without one
+// was interrupted.
+func Active(dir, id string) bool {
+	return x_lock.Inspect(RunDir(dir, id), runLockName).Held
+}
+
+// ArtifactDir returns the directory holding the artifacts of a task in a run.
+func ArtifactDir(dir, id, task string) string {
+	return filepath.Join(RunDir(dir, id), "artifacts", x_artifact.DirName(task))
+}
+
+// Prune drops the runs outside the retention from the runs directory,
+// except runs a process still executes. It returns the IDs of the dropped
+// runs.
+func Prune(dir string, r x_store.Retention) ([]string, error) {
+	store := x_store.New(dir)
+	return prune(store, r)
+}
+
+// Latest returns the most recent finished state of every task in states,
+// which must be ordered newest first as returned by List.
+func Latest(states []*State) map[string]*TaskState {
+	latest := make(map[string]*TaskState)
+	for _, s := range states {
+		for _, ts := range s.Tasks {
+			if _, ok := latest[ts.Name]; ok {
+				continue
+			}
+			switch ts.Status {
+			case StatusSuccess, StatusFailed, StatusSkipped:
+				latest[ts.Name] = ts
+			}
+		}
+	}
+	return latest
+}
+
+// Duration returns how long the task ran, or zero if it did not finish.
+func (ts *TaskState) Duration() time.Duration {
+	if ts.Started.IsZero() || ts.Finished.IsZero() {
+		return 0
+	}
+	return ts.Finished.Sub(ts.Started)
+}
+
+// Task returns the state of a task by name, or nil.
+func (s *State) Task(name string) *TaskState {
+	for _, ts := range s.Tasks {
+		if ts.Name == name {
+			return ts
+		}
+	}
+	return nil
+}
+
+// Regressions returns the tasks of the run that ran slower than their
+// duration baseline.
+func (s *State) Regressions() []*TaskState {
+	var slow []*TaskState
+	for _, ts := range s.Tasks {
+		if ts.Regression != nil {
+			slow = append(slow, ts)
+		}
+	}
+	return slow
+}
+
+// Failed reports whether any task in the run failed.
+func (s *State) Failed() bool {
+	for _, ts := range s.Tasks {
+		if ts.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the state and every task state to the run store.
+func (s *State) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Updated = time.Now()
+	for _, ts := range s.Tasks {
+		if err := s.saveTask(ts); err != nil {
+			return err
+		}
+	}
+	return s.save()
+}
+
+// update applies fn to the state under its lock and saves the state without
+// its tasks. Changes to a task go through updateTask.
+func (s *State) update(fn func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+	s.Updated = time.Now()
+	return s.save()
+}
+
+// updateTask applies fn to the state under its lock and saves ts.
+func (s *State) updateTask(ts *TaskState, fn func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+	s.Updated = time.Now()
+	return s.saveTask(ts)
+}
+
+// save writes the state without its tasks; the caller must hold the lock.
+func (s *State) save() error {
+	record := stateRecord{State: s}
+	for _, ts := range s.Tasks {
+		record.Tasks = append(record.Tasks, ts.Name)
+	}
+	if err := s.store.Put(s.ID, stateKey, record); err != nil {
+		return fmt.Errorf("failed to write run state: %w", err)
+	}
+	return nil
+}
+
+// saveTask writes the state of a task; the caller must hold the lock.
+func (s *State) saveTask(ts *TaskState) error {
+	if err := s.store.Put(s.ID, taskKey+ts.Name, ts); err != nil {
+		return fmt.Errorf("failed to write state of task %s: %w", ts.Name, err)
+	}
+	return nil
+}
+
+//
+// ---------- Store ----------
+
+// decodeState assembles the state of a run from its state and task records.
+// It returns nil if the records hold no state. Updated is the time of the
+// latest checkpoint, which may be of a task.
+func decodeState(store *x_store.Store, records []x_store.Record) (*State, error) {
+	s := &State{store: store}
+	record := stateRecord{State: s}
+	found := false
+	tasks := make(map[string]*TaskState)
+	var updated time.Time
+	for _, r := range records {
+		name, isTask := strings.CutPrefix(r.Key, taskKey)
+		switch {
+		case r.Key == stateKey:
+			if err := json.Unmarshal(r.Value, &record); err != nil {
+				return nil, err
+			}
+			found = true
+		case isTask:
+			ts := &TaskState{}
+			if err := json.Unmarshal(r.Value, ts); err != nil {
+				return nil, fmt.Errorf("failed to parse state of task %s: %w", name, err)
+			}
+			tasks[name] = ts
+			if r.Time.After(updated) {
+				updated = r.Time
+			}
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	for _, name := range record.Tasks {
+		ts := tasks[name]
+		if ts == nil {
+			ts = &TaskState{Name: name, Status: StatusPending}
+		}
+		s.Tasks = append(s.Tasks, ts)
+	}
+	if updated.After(s.Updated) {
+		s.Updated = updated
+	}
+	return s, nil
+}
+
+// baselines returns the latest duration baseline of every task in store,
+// recorded by the newest run that executed it.
+func baselines(store *x_store.Store) (map[string]x_baseline.Baseline, error) {
+	records, err := store.Scan(baselineKey)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]x_baseline.Baseline)
+	for _, r := range records {
+		task := strings.TrimPrefix(r.Key, baselineKey)
+		if _, ok := latest[task]; ok {
+			continue
+		}
+		var b x_baseline.Baseline
+		if err := json.Unmarshal(r.Value, &b); err != nil {
+			x_log.Warn().
+				Err(err).
+				Str("run", r.Run).
+				Str("key", r.Key).
+				Msg("skipping unreadable baseline")
+			continue
+		}
+		latest[task] = b
+	}
+	return latest, nil
+}
+
+// prune drops the runs outside the retention from store together with
+// their run directories. Runs a process still executes are kept.
+func prune(store *x_store.Store, r x_store.Retention) ([]string, error) {
+	dropped, err := store.Prune(r, time.Now(), func(id string) bool { return Active(store.Dir, id) })
+	for _, id := range dropped {
+		if rmErr := os.RemoveAll(RunDir(store.Dir, id)); rmErr != nil && err == nil {
+			err = fmt.Errorf("failed to remove run dir: %w", rmErr)
+		}
+	}
+	return dropped, err
+}
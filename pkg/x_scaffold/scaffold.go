@@ -0,0 +1,220 @@
+package x_scaffold
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+
+	"github.com/rskv-p/jtask/pkg/x_config"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Project Types ----------
+
+// Project is a detected project type with the tasks proposed for it.
+type Project struct {
+	Kind   string         // Project type, e.g. "go"
+	Marker string         // File that identified the project
+	Tasks  []*x_task.Task // Proposed tasks
+}
+
+// detector recognizes a project type by a marker file in the directory.
+type detector struct {
+	kind    string
+	marker  string
+	propose func(dir string) []*x_task.Task
+}
+
+// detectors are checked in order; every match contributes its tasks.
+var detectors = []detector{
+	{"go", "go.mod", proposeGo},
+	{"node", "package.json", proposeNode},
+	{"rust", "Cargo.toml", proposeRust},
+	{"python", "pyproject.toml", proposePython},
+	{"make", "Makefile", proposeMake},
+}
+
+//
+// ---------- Public Functions ----------
+
+// Detect returns the project types found in dir.
+func Detect(dir string) []Project {
+	var projects []Project
+	for _, d := range detectors {
+		if _, err := os.Stat(filepath.Join(dir, d.marker)); err != nil {
+			continue
+		}
+		tasks := d.propose(dir)
+		if len(tasks) == 0 {
+			continue
+		}
+		projects = append(projects, Project{Kind: d.kind, Marker: d.marker, Tasks: tasks})
+	}
+
+	x_log.Debug().
+		Str("dir", dir).
+		Int("projects", len(projects)).
+		Msg("project types detected")
+	return projects
+}
+
+// Proposals returns the proposed tasks of all projects. With more than one
+// project, task names are prefixed with the project kind, e.g. "go:test".
+func Proposals(projects []Project) []*x_task.Task {
+	var tasks []*x_task.Task
+	for _, p := range projects {
+		for _, t := range p.Tasks {
+			t := *t
+			if len(projects) > 1 {
+				t.Name = p.Kind + ":" + t.Name
+			}
+			tasks = append(tasks, &t)
+		}
+	}
+	return tasks
+}
+
+//
+// ---------- Proposals ----------
+
+// task builds a proposed task; test and lint tasks are tagged "ci".
+func task(name, description string, exec ...string) *x_task.Task {
+	t := &x_task.Task{Name: name, Description: description, Exec: exec}
+	if name == "test" || name == "lint" {
+		t.Tags = []string{"ci"}
+	}
+	return t
+}
+
+func proposeGo(string) []*x_task.Task {
+	return []*x_task.Task{
+		task("build", "Build all packages", "go", "build", "./..."),
+		task("test", "Run the tests", "go", "test", "./..."),
+		task("lint", "Vet the code", "go", "vet", "./..."),
+		task("fmt", "Format the code", "gofmt", "-l", "-w", "."),
+	}
+}
+
+func proposeRust(string) []*x_task.Task {
+	return []*x_task.Task{
+		task("build", "Build the crate", "cargo", "build"),
+		task("test", "Run the tests", "cargo", "test"),
+		task("lint", "Lint with clippy", "cargo", "clippy"),
+		task("fmt", "Format the code", "cargo", "fmt"),
+	}
+}
+
+func proposePython(string) []*x_task.Task {
+	return []*x_task.Task{
+		task("build", "Build the package", "python", "-m", "build"),
+		task("test", "Run the tests", "pytest"),
+		task("lint", "Lint with ruff", "ruff", "check", "."),
+		task("fmt", "Format with ruff", "ruff", "format", "."),
+	}
+}
+
+// proposeNode proposes tasks for the package.json scripts named like common tasks.
+func proposeNode(dir string) []*x_task.Task {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		x_log.Warn().
+			Err(err).
+			Msg("failed to parse package.json")
+		return nil
+	}
+
+	var tasks []*x_task.Task
+	for _, name := range []string{"build", "test", "lint", "fmt", "format"} {
+		if _, ok := pkg.Scripts[name]; ok {
+			tasks = append(tasks, task(name, "Run the "+name+" script", "npm", "run", name))
+		}
+	}
+	return tasks
+}
+
+// makeTarget matches a rule line of a Makefile.
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+
+// proposeMake proposes tasks for the Makefile targets named like common tasks.
+func proposeMake(dir string) []*x_task.Task {
+	targets, err := makeTargets(filepath.Join(dir, "Makefile"))
+	if err != nil {
+		return nil
+	}
+
+	var tasks []*x_task.Task
+	for _, name := range []string{"build", "test", "lint", "fmt", "format", "check"} {
+		if slices.Contains(targets, name) {
+			tasks = append(tasks, task(name, "Run make "+name, "make", name))
+		}
+	}
+	return tasks
+}
+
+// makeTargets returns the targets defined in a Makefile.
+func makeTargets(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var targets []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := makeTarget.FindStringSubmatch(scanner.Text()); m != nil {
+			targets = append(targets, m[1])
+		}
+	}
+	return targets, scanner.Err()
+}
+
+//
+// ---------- Files ----------
+
+// AppConfig returns the app config for a new project: logs go to a rotated
+// file under the space directory and only warnings reach the console.
+func AppConfig(name string) *x_config.Config {
+	return &x_config.Config{
+		AppName:       name,
+		Version:       "1.0.0",
+		MaxConcurrent: max(runtime.NumCPU(), 2),
+		Space:         x_config.DefaultSpace,
+		Logger: x_log.Config{
+			Level:      "info",
+			LogFile:    filepath.Join(x_config.DefaultSpace, "log", "jtask.log"),
+			ToConsole:  false,
+			ToFile:     true,
+			Style:      "dark",
+			MaxSize:    10,
+			MaxBackups: 5,
+			MaxAge:     7,
+			Compress:   true,
+		},
+	}
+}
+
+// WriteTasks writes a tasks file, replacing any existing one.
+func WriteTasks(path string, c *x_task.TaskCollection) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tasks: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write tasks file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package x_scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Helpers ----------
+
+// writeFiles creates files with contents in dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// names returns the names of tasks joined by commas.
+func names(tasks []*x_task.Task) string {
+	var out []string
+	for _, t := range tasks {
+		out = append(out, t.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestDetectSingle verifies plain task names for a single project type.
+func TestDetectSingle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Cargo.toml": "[package]\n"})
+
+	projects := Detect(dir)
+	if len(projects) != 1 || projects[0].Kind != "rust" {
+		t.Fatalf("unexpected projects: %+v", projects)
+	}
+	if got := names(Proposals(projects)); got != "build,test,lint,fmt" {
+		t.Errorf("proposals = %s", got)
+	}
+}
+
+// TestDetectScriptsAndTargets verifies that package.json scripts and Makefile
+// targets are only proposed when they exist, prefixed by project kind.
+func TestDetectScriptsAndTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"scripts": {"test": "jest", "lint": "eslint .", "start": "node ."}}`,
+		"Makefile":     ".PHONY: build\nCC := gcc\nbuild: deps\n\tcc main.c\nclean:\n\trm -f a.out\n",
+	})
+
+	proposals := Proposals(Detect(dir))
+	if got := names(proposals); got != "node:test,node:lint,make:build" {
+		t.Errorf("proposals = %s", got)
+	}
+	if got := strings.Join(proposals[2].Exec, " "); got != "make build" {
+		t.Errorf("make exec = %s", got)
+	}
+	if len(proposals[0].Tags) != 1 || proposals[0].Tags[0] != "ci" {
+		t.Errorf("test task tags = %v", proposals[0].Tags)
+	}
+}
+
+// TestDetectNone verifies that an empty directory yields no projects.
+func TestDetectNone(t *testing.T) {
+	if projects := Detect(t.TempDir()); len(projects) != 0 {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+}
+
+// TestWriteTasks verifies that written tasks load back.
+func TestWriteTasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	c := &x_task.TaskCollection{Name: "demo", Data: Proposals([]Project{{Kind: "go", Tasks: proposeGo("")}})}
+	if err := WriteTasks(path, c); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := x_task.LoadTasks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "demo" || names(loaded.Data) != "build,test,lint,fmt" {
+		t.Errorf("unexpected loaded tasks: %+v", loaded)
+	}
+}
@@ -0,0 +1,295 @@
+package x_select
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Selector ----------
+
+// Selector decides whether a task is selected.
+type Selector interface {
+	Match(t *x_task.Task) bool
+}
+
+// nameSel matches task names against a glob.
+type nameSel struct{ glob string }
+
+func (s nameSel) Match(t *x_task.Task) bool {
+	ok, _ := path.Match(s.glob, t.Name)
+	return ok
+}
+
+// tagSel matches tasks having a tag that matches a glob.
+type tagSel struct{ glob string }
+
+func (s tagSel) Match(t *x_task.Task) bool {
+	for _, tag := range t.Tags {
+		if ok, _ := path.Match(s.glob, tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// notSel negates a selector.
+type notSel struct{ s Selector }
+
+func (s notSel) Match(t *x_task.Task) bool { return !s.s.Match(t) }
+
+// andSel matches when both selectors match.
+type andSel struct{ a, b Selector }
+
+func (s andSel) Match(t *x_task.Task) bool { return s.a.Match(t) && s.b.Match(t) }
+
+// orSel matches when either selector matches.
+type orSel struct{ a, b Selector }
+
+func (s orSel) Match(t *x_task.Task) bool { return s.a.Match(t) || s.b.Match(t) }
+
+//
+// ---------- Public Functions ----------
+
+// Parse parses a selector expression.
+//
+//	build            task named "build"
+//	test:*           tasks whose name matches the glob
+//	tag:ci           tasks tagged "ci" (tag globs work too)
+//	name:test:*      explicit name glob
+//	!a, a && b, a || b, (a)
+func Parse(expr string) (Selector, error) {
+	p := &parser{tokens: tokenize(expr)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	s, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid selector %q: unexpected %q", expr, p.tokens[p.pos])
+	}
+	return s, nil
+}
+
+// Select returns the names of the tasks chosen by args and tags, in
+// collection order. Each arg is an exact task name or a selector expression;
+// args are OR'ed. Tasks must also carry one of tags, if any are given.
+// Every arg must match at least one task.
+func Select(c *x_task.TaskCollection, args []string, tags []string) ([]string, error) {
+	var sel Selector
+	for _, arg := range args {
+		var s Selector
+		if c.Find(arg) != nil {
+			// Exact names win, even if they contain spaces or glob characters
+			s = exactSel{arg}
+		} else {
+			var err error
+			if s, err = Parse(arg); err != nil {
+				return nil, err
+			}
+		}
+		if !matchesAny(c, s) {
+			return nil, fmt.Errorf("no tasks match %q", arg)
+		}
+		if sel == nil {
+			sel = s
+		} else {
+			sel = orSel{sel, s}
+		}
+	}
+
+	if len(tags) > 0 {
+		var tagAny Selector
+		for _, tag := range tags {
+			if tagAny == nil {
+				tagAny = tagSel{tag}
+			} else {
+				tagAny = orSel{tagAny, tagSel{tag}}
+			}
+		}
+		if sel == nil {
+			sel = tagAny
+		} else {
+			sel = andSel{sel, tagAny}
+		}
+	}
+
+	if sel == nil {
+		return nil, nil
+	}
+
+	var names []string
+	for _, t := range c.Data {
+		if sel.Match(t) {
+			names = append(names, t.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no tasks match the selection")
+	}
+
+	x_log.Debug().
+		Strs("args", args).
+		Strs("tags", tags).
+		Strs("selected", names).
+		Msg("tasks selected")
+	return names, nil
+}
+
+//
+// ---------- Helper Functions ----------
+
+// exactSel matches a single task name literally.
+type exactSel struct{ name string }
+
+func (s exactSel) Match(t *x_task.Task) bool { return t.Name == s.name }
+
+// matchesAny reports whether the selector matches at least one task.
+func matchesAny(c *x_task.TaskCollection, s Selector) bool {
+	for _, t := range c.Data {
+		if s.Match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// ---------- Parser ----------
+
+// tokenize splits an expression into operators, parentheses and atoms.
+func tokenize(expr string) []string {
+	var tokens []string
+	var atom strings.Builder
+	flush := func() {
+		if atom.Len() > 0 {
+			tokens = append(tokens, atom.String())
+			atom.Reset()
+		}
+	}
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			flush()
+		case c == '(' || c == ')' || c == '!':
+			flush()
+			tokens = append(tokens, string(c))
+		case (c == '&' || c == '|') && i+1 < len(expr) && expr[i+1] == c:
+			flush()
+			tokens = append(tokens, expr[i:i+2])
+			i++
+		default:
+			atom.WriteByte(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parser is a recursive descent parser over tokens.
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (Selector, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orSel{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Selector, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andSel{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Selector, error) {
+	switch tok := p.peek(); tok {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "!":
+		p.pos++
+		s, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notSel{s}, nil
+	case "(":
+		p.pos++
+		s, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return s, nil
+	case ")", "&&", "||":
+		return nil, fmt.Errorf("unexpected %q", tok)
+	default:
+		p.pos++
+		return parseAtom(tok)
+	}
+}
+
+// parseAtom parses "tag:glob", "name:glob" or a bare name glob.
+func parseAtom(tok string) (Selector, error) {
+	var s Selector
+	switch {
+	case strings.HasPrefix(tok, "tag:"):
+		s = tagSel{strings.TrimPrefix(tok, "tag:")}
+	case strings.HasPrefix(tok, "name:"):
+		s = nameSel{strings.TrimPrefix(tok, "name:")}
+	default:
+		s = nameSel{tok}
+	}
+
+	// Reject malformed globs early
+	glob := strings.TrimPrefix(strings.TrimPrefix(tok, "tag:"), "name:")
+	if glob == "" {
+		return nil, fmt.Errorf("empty pattern in %q", tok)
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, fmt.Errorf("bad pattern %q: %w", glob, err)
+	}
+	return s, nil
+}
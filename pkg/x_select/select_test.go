@@ -0,0 +1,81 @@
+package x_select
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Mock Data ----------
+
+// collection provides tasks with namespaced names and tags.
+var collection = &x_task.TaskCollection{Data: []*x_task.Task{
+	{Name: "build", Tags: []string{"ci"}},
+	{Name: "test:unit", Tags: []string{"ci"}},
+	{Name: "test:e2e", Tags: []string{"ci", "slow"}},
+	{Name: "lint", Tags: []string{"dev"}},
+	{Name: "deploy prod"},
+}}
+
+//
+// ---------- Unit Tests ----------
+
+// TestSelect verifies selection by names, globs, tags and expressions.
+func TestSelect(t *testing.T) {
+	cases := []struct {
+		args []string
+		tags []string
+		want string
+	}{
+		{[]string{"lint"}, nil, "lint"},
+		{[]string{"deploy prod"}, nil, "deploy prod"},
+		{[]string{"test:*"}, nil, "test:unit,test:e2e"},
+		{[]string{"lint", "build"}, nil, "build,lint"},
+		{nil, []string{"ci"}, "build,test:unit,test:e2e"},
+		{[]string{"test:*"}, []string{"slow"}, "test:e2e"},
+		{[]string{"tag:ci && !tag:slow"}, nil, "build,test:unit"},
+		{[]string{"tag:dev || name:build"}, nil, "build,lint"},
+		{[]string{"!(tag:ci || lint)"}, nil, "deploy prod"},
+	}
+
+	for _, c := range cases {
+		names, err := Select(collection, c.args, c.tags)
+		if err != nil {
+			t.Errorf("Select(%v, %v) returned error: %v", c.args, c.tags, err)
+			continue
+		}
+		if got := strings.Join(names, ","); got != c.want {
+			t.Errorf("Select(%v, %v) = %s, want %s", c.args, c.tags, got, c.want)
+		}
+	}
+}
+
+// TestSelectEmpty checks that no args and no tags select nothing.
+func TestSelectEmpty(t *testing.T) {
+	names, err := Select(collection, nil, nil)
+	if err != nil || names != nil {
+		t.Errorf("expected empty selection, got %v, %v", names, err)
+	}
+}
+
+// TestSelectErrors checks unmatched args and malformed expressions.
+func TestSelectErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"missing"},
+		{"lint", "nope:*"},
+		{"tag:ci &&"},
+		{"(tag:ci"},
+		{"tag:ci )"},
+		{"[x"},
+	} {
+		if _, err := Select(collection, args, nil); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+
+	if _, err := Select(collection, []string{"lint"}, []string{"ci"}); err == nil {
+		t.Error("expected error when tags exclude every selected task")
+	}
+}
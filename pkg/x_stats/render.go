@@ -0,0 +1,146 @@
+package x_stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+//
+// ---------- Rendering ----------
+
+// Formats lists the supported output formats.
+var Formats = []string{"table", "csv", "json"}
+
+// sparkBars are the levels of a sparkline, lowest first.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Render writes stats in format: table, csv or json.
+func Render(w io.Writer, stats []TaskStats, format string) error {
+	switch format {
+	case "", "table":
+		_, err := fmt.Fprintln(w, renderTable(stats))
+		return err
+	case "csv":
+		return renderCSV(w, stats)
+	case "json":
+		if stats == nil {
+			stats = []TaskStats{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	default:
+		return fmt.Errorf("unknown format %q (want one of: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Sparkline renders the durations of a trend scaled between its shortest and
+// longest execution. Failed executions are red.
+func Sparkline(trend []Sample) string {
+	if len(trend) == 0 {
+		return ""
+	}
+	lo, hi := trend[0].DurationMS, trend[0].DurationMS
+	for _, s := range trend {
+		lo, hi = min(lo, s.DurationMS), max(hi, s.DurationMS)
+	}
+
+	ok := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	failed := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	var b strings.Builder
+	for _, s := range trend {
+		level := 0
+		if hi > lo {
+			level = int((s.DurationMS - lo) * int64(len(sparkBars)-1) / (hi - lo))
+		}
+		style := ok
+		if s.Failed {
+			style = failed
+		}
+		b.WriteString(style.Render(string(sparkBars[level])))
+	}
+	return b.String()
+}
+
+// renderTable renders stats as a terminal table.
+func renderTable(stats []TaskStats) string {
+	header := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cell := lipgloss.NewStyle().Padding(0, 1)
+	warn := cell.Foreground(lipgloss.Color("3"))
+	bad := cell.Foreground(lipgloss.Color("1"))
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		Headers("TASK", "RUNS", "P50", "P90", "P99", "FAILURE", "FLAKE", "TREND").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return header
+			case col == 5 && stats[row].FailureRate > 0:
+				return bad
+			case col == 6 && stats[row].FlakeRate > 0:
+				return warn
+			default:
+				return cell
+			}
+		})
+	for _, s := range stats {
+		t.Row(
+			s.Task,
+			strconv.Itoa(s.Executions),
+			millis(s.P50MS),
+			millis(s.P90MS),
+			millis(s.P99MS),
+			rate(s.FailureRate),
+			rate(s.FlakeRate),
+			Sparkline(s.Trend),
+		)
+	}
+	return t.Render()
+}
+
+// renderCSV writes stats as CSV with a header row.
+func renderCSV(w io.Writer, stats []TaskStats) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"task", "executions", "failures", "flakes", "failure_rate", "flake_rate",
+		"p50_ms", "p90_ms", "p99_ms", "last", "trend_ms"})
+	for _, s := range stats {
+		var trend []string
+		for _, sample := range s.Trend {
+			trend = append(trend, strconv.FormatInt(sample.DurationMS, 10))
+		}
+		out.Write([]string{
+			s.Task,
+			strconv.Itoa(s.Executions),
+			strconv.Itoa(s.Failures),
+			strconv.Itoa(s.Flakes),
+			strconv.FormatFloat(s.FailureRate, 'f', 4, 64),
+			strconv.FormatFloat(s.FlakeRate, 'f', 4, 64),
+			strconv.FormatInt(s.P50MS, 10),
+			strconv.FormatInt(s.P90MS, 10),
+			strconv.FormatInt(s.P99MS, 10),
+			s.Last,
+			strings.Join(trend, " "),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// millis formats milliseconds as a duration.
+func millis(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).String()
+}
+
+// rate formats a rate as a percentage.
+func rate(r float64) string {
+	return strconv.FormatFloat(r*100, 'f', 1, 64) + "%"
+}
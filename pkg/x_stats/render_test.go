@@ -0,0 +1,49 @@
+package x_stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestSparkline verifies that durations are scaled between the extremes.
+func TestSparkline(t *testing.T) {
+	trend := []Sample{{DurationMS: 100}, {DurationMS: 450}, {DurationMS: 800, Failed: true}, {DurationMS: 100}}
+	if got := Sparkline(trend); got != "▁▄█▁" {
+		t.Errorf("Sparkline = %q", got)
+	}
+	if got := Sparkline([]Sample{{DurationMS: 5}, {DurationMS: 5}}); got != "▁▁" {
+		t.Errorf("Sparkline of equal durations = %q", got)
+	}
+}
+
+// TestRender verifies the CSV and JSON exports.
+func TestRender(t *testing.T) {
+	stats := []TaskStats{{Task: "build", Executions: 2, P50MS: 1500, FailureRate: 0.5, Trend: []Sample{{DurationMS: 1000}, {DurationMS: 2000}}}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, stats, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "build,2,0,0,0.5000,0.0000,1500,") || !strings.HasSuffix(lines[1], ",1000 2000") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Render(&buf, stats, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []TaskStats
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded[0].P50MS != 1500 {
+		t.Errorf("unexpected JSON: %v\n%s", err, buf.String())
+	}
+
+	if err := Render(&buf, stats, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
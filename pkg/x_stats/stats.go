@@ -0,0 +1,165 @@
+package x_stats
+
+import (
+	"math"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+// Statistics are computed from executions: every recorded x_task.Result of
+// a task. A failure counts as a flake when the next execution of the same
+// task definition passed, whether that was a resume of the run or the next
+// run.
+
+//
+// ---------- Data Structures ----------
+
+// Execution is one execution of a task.
+type Execution struct {
+	Task     string        // Task name
+	Hash     string        // Fingerprint of the task definition
+	Run      string        // Run ID
+	Started  time.Time     // When the command started
+	Duration time.Duration // How long it ran
+	Failed   bool          // Whether it failed
+}
+
+// Sample is one point of a duration trend.
+type Sample struct {
+	DurationMS int64 `json:"duration_ms"` // Duration in milliseconds
+	Failed     bool  `json:"failed"`      // Whether the execution failed
+}
+
+// TaskStats summarizes the executions of one task.
+type TaskStats struct {
+	Task        string   `json:"task"`         // Task name
+	Executions  int      `json:"executions"`   // Number of executions
+	Failures    int      `json:"failures"`     // Failed executions
+	Flakes      int      `json:"flakes"`       // Failures followed by a pass of the same definition
+	FailureRate float64  `json:"failure_rate"` // Failures per execution, 0 to 1
+	FlakeRate   float64  `json:"flake_rate"`   // Flakes per execution, 0 to 1
+	P50MS       int64    `json:"p50_ms"`       // Median duration in milliseconds
+	P90MS       int64    `json:"p90_ms"`       // 90th percentile duration
+	P99MS       int64    `json:"p99_ms"`       // 99th percentile duration
+	Last        string   `json:"last"`         // When the task last ran, RFC 3339
+	Trend       []Sample `json:"trend"`        // The most recent executions, oldest first
+}
+
+// Options select and shape the statistics.
+type Options struct {
+	Task  string    // Glob of task names; empty for all tasks
+	Since time.Time // Only executions started at or after this time
+	Trend int       // Number of recent executions in the trend
+}
+
+//
+// ---------- Public Functions ----------
+
+// Executions flattens runs and their recorded results, keyed by run ID as
+// returned by x_run.AllResults, into executions ordered by start time.
+func Executions(states []*x_run.State, results map[string][]*x_task.Result) []Execution {
+	var out []Execution
+	for _, s := range states {
+		byTask := make(map[string][]*x_task.Result)
+		for _, r := range results[s.ID] {
+			byTask[r.Name] = append(byTask[r.Name], r)
+		}
+
+		for _, ts := range s.Tasks {
+			for _, r := range byTask[ts.Name] {
+				if r.Started.IsZero() || r.Finished.IsZero() {
+					continue
+				}
+				out = append(out, Execution{
+					Task:     ts.Name,
+					Hash:     ts.Hash,
+					Run:      s.ID,
+					Started:  r.Started,
+					Duration: r.Finished.Sub(r.Started),
+					Failed:   r.Error != "",
+				})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
+
+// Compute returns the statistics of every task with executions matching
+// opts, ordered by task name.
+func Compute(executions []Execution, opts Options) []TaskStats {
+	byTask := make(map[string][]Execution)
+	for _, e := range executions {
+		if !opts.Since.IsZero() && e.Started.Before(opts.Since) {
+			continue
+		}
+		if opts.Task != "" {
+			if ok, _ := path.Match(opts.Task, e.Task); !ok {
+				continue
+			}
+		}
+		byTask[e.Task] = append(byTask[e.Task], e)
+	}
+
+	var stats []TaskStats
+	for task, es := range byTask {
+		stats = append(stats, summarize(task, es, opts.Trend))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Task < stats[j].Task })
+	return stats
+}
+
+//
+// ---------- Helper Functions ----------
+
+// summarize computes the statistics of the executions of one task, which
+// are ordered by start time.
+func summarize(task string, es []Execution, trend int) TaskStats {
+	s := TaskStats{Task: task, Executions: len(es), Last: es[len(es)-1].Started.Format(time.RFC3339)}
+
+	var passed, all []time.Duration
+	for i, e := range es {
+		all = append(all, e.Duration)
+		if !e.Failed {
+			passed = append(passed, e.Duration)
+			continue
+		}
+		s.Failures++
+		if i+1 < len(es) && !es[i+1].Failed && es[i+1].Hash == e.Hash {
+			s.Flakes++
+		}
+	}
+	s.FailureRate = float64(s.Failures) / float64(len(es))
+	s.FlakeRate = float64(s.Flakes) / float64(len(es))
+
+	// Failures often end early, so percentiles use passing executions if any
+	durations := passed
+	if len(durations) == 0 {
+		durations = all
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	s.P50MS = Percentile(durations, 50).Milliseconds()
+	s.P90MS = Percentile(durations, 90).Milliseconds()
+	s.P99MS = Percentile(durations, 99).Milliseconds()
+
+	if trend > 0 && len(es) > trend {
+		es = es[len(es)-trend:]
+	}
+	for _, e := range es {
+		s.Trend = append(s.Trend, Sample{DurationMS: e.Duration.Milliseconds(), Failed: e.Failed})
+	}
+	return s
+}
+
+// Percentile returns the nearest-rank percentile p of sorted durations.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,81 @@
+package x_stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_run"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestExecutions verifies that every recorded result of a run is an
+// execution, carrying the definition hash of its task.
+func TestExecutions(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	states := []*x_run.State{
+		{ID: "new", Tasks: []*x_run.TaskState{{Name: "test", Hash: "h2", Status: x_run.StatusSuccess}}},
+		{ID: "old", Tasks: []*x_run.TaskState{
+			{Name: "test", Hash: "h1", Status: x_run.StatusFailed},
+			{Name: "deploy", Status: x_run.StatusSkipped},
+		}},
+	}
+	results := map[string][]*x_task.Result{
+		"new": {
+			{Name: "test", Started: start.Add(time.Hour), Finished: start.Add(time.Hour + time.Second), Error: "exit status 1"},
+			{Name: "test", Started: start.Add(2 * time.Hour), Finished: start.Add(2*time.Hour + 2*time.Second)},
+		},
+		"old": {
+			{Name: "test", Started: start, Finished: start.Add(time.Second), Error: "exit status 1"},
+			{Name: "test", Started: start.Add(time.Minute)}, // Never finished
+		},
+	}
+
+	es := Executions(states, results)
+	if len(es) != 3 || es[0].Run != "old" || !es[0].Failed || es[0].Hash != "h1" || es[2].Duration != 2*time.Second || es[2].Hash != "h2" {
+		t.Errorf("unexpected executions: %+v", es)
+	}
+}
+
+// TestCompute verifies percentiles, failure and flake rates, filters and the trend.
+func TestCompute(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	var es []Execution
+	add := func(task, hash string, seconds int, failed bool) {
+		es = append(es, Execution{
+			Task:     task,
+			Hash:     hash,
+			Started:  start.Add(time.Duration(len(es)) * time.Minute),
+			Duration: time.Duration(seconds) * time.Second,
+			Failed:   failed,
+		})
+	}
+	for i := 1; i <= 10; i++ {
+		add("build", "b", i, false)
+	}
+	add("test", "t1", 1, true)
+	add("test", "t1", 5, false) // flake: passes on rerun
+	add("test", "t1", 1, true)
+	add("test", "t2", 6, false) // changed definition: a fix, not a flake
+
+	stats := Compute(es, Options{Trend: 3})
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 tasks, got %+v", stats)
+	}
+	build, test := stats[0], stats[1]
+	if build.P50MS != 5000 || build.P90MS != 9000 || build.P99MS != 10000 || build.FailureRate != 0 {
+		t.Errorf("unexpected build stats: %+v", build)
+	}
+	if len(build.Trend) != 3 || build.Trend[2].DurationMS != 10000 {
+		t.Errorf("unexpected build trend: %+v", build.Trend)
+	}
+	if test.Failures != 2 || test.Flakes != 1 || test.FailureRate != 0.5 || test.FlakeRate != 0.25 || test.P50MS != 5000 {
+		t.Errorf("unexpected test stats: %+v", test)
+	}
+
+	if got := Compute(es, Options{Task: "te*", Since: start.Add(11 * time.Minute)}); len(got) != 1 || got[0].Executions != 3 {
+		t.Errorf("unexpected filtered stats: %+v", got)
+	}
+}
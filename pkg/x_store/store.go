@@ -0,0 +1,407 @@
+package x_store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_util"
+)
+
+// The store is a directory of append-only JSONL segments, one per day. Every
+// record belongs to a run and lands in the segment of the day the run was
+// created, derived from its ULID, so that pruning old runs rewrites or drops
+// whole segments. A later record for the same run and key replaces an earlier
+// one. Writers append under an exclusive file lock, which makes parallel tasks
+// and several jt processes safe; compaction rewrites a segment under the same
+// lock once the records replaced in it pass compactSize.
+
+//
+// ---------- Errors ----------
+
+// ErrNotFound is returned by Get when a run has no record with the key.
+var ErrNotFound = errors.New("record not found")
+
+// compactSize is the size of replaced record values from which Prune
+// compacts a segment that holds no dropped runs.
+const compactSize = 1 << 20
+
+//
+// ---------- Data Structures ----------
+
+// Record is one entry of the store.
+type Record struct {
+	Run   string          `json:"run"`   // Run the record belongs to
+	Key   string          `json:"key"`   // Key within the run, e.g. "state"
+	Time  time.Time       `json:"time"`  // When the record was written
+	Value json.RawMessage `json:"value"` // JSON encoded value
+}
+
+// Retention limits the runs kept by Prune. Zero values keep everything.
+type Retention struct {
+	MaxAge  time.Duration // Drop runs created longer ago than this
+	MaxRuns int           // Keep only this many of the newest runs
+}
+
+// Store is an embedded run store in a directory.
+type Store struct {
+	Dir string // Directory holding the segments
+}
+
+//
+// ---------- Public Functions ----------
+
+// New returns the store in dir. The directory is created on the first write.
+func New(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Put appends a record with the JSON encoding of v.
+func (s *Store) Put(run, key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s of run %s: %w", key, run, err)
+	}
+	line, err := json.Marshal(Record{Run: run, Key: key, Time: time.Now(), Value: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create store dir: %w", err)
+	}
+
+	path := s.segment(run)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open store segment: %w", err)
+		}
+		if err := x_lock.LockFile(f, true, true); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to lock store segment: %w", err)
+		}
+
+		// Compaction may have replaced the file while we waited for the lock
+		if !sameFile(f, path) {
+			f.Close()
+			continue
+		}
+
+		_, err = f.Write(append(line, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write store segment: %w", err)
+		}
+		return nil
+	}
+}
+
+// Get decodes the latest record of run with key into v.
+func (s *Store) Get(run, key string, v any) error {
+	records, err := readSegment(s.segment(run))
+	if err != nil {
+		return err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if r := records[i]; r.Run == run && r.Key == key {
+			return json.Unmarshal(r.Value, v)
+		}
+	}
+	return ErrNotFound
+}
+
+// Records returns the latest record of every key of a run, ordered by key.
+func (s *Store) Records(run string) ([]Record, error) {
+	records, err := readSegment(s.segment(run))
+	if err != nil {
+		return nil, err
+	}
+	var own []Record
+	for _, r := range latest(records) {
+		if r.Run == run {
+			own = append(own, r)
+		}
+	}
+	sort.Slice(own, func(i, j int) bool { return own[i].Key < own[j].Key })
+	return own, nil
+}
+
+// Scan returns the latest record of every run and key whose key starts with
+// one of prefixes, newest run first and ordered by key within a run.
+func (s *Store) Scan(prefixes ...string) ([]Record, error) {
+	paths, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Record
+	for _, path := range paths {
+		records, err := readSegment(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range latest(records) {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(r.Key, prefix) {
+					all = append(all, r)
+					break
+				}
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Run != all[j].Run {
+			return all[i].Run > all[j].Run
+		}
+		return all[i].Key < all[j].Key
+	})
+	return all, nil
+}
+
+// Prune drops the runs outside the retention, except those for which keep,
+// if not nil, returns true. Segments that held dropped runs, or replaced
+// records past compactSize, are compacted so that only the latest record of
+// every key is kept. It returns the IDs of the dropped runs.
+func (s *Store) Prune(r Retention, now time.Time, keep func(run string) bool) ([]string, error) {
+	paths, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the runs of all segments, newest first, and the size of the
+	// records replaced by later ones in every segment
+	var runs []string
+	seen := make(map[string]bool)
+	segmentRuns := make(map[string][]string)
+	replaced := make(map[string]int)
+	for _, path := range paths {
+		records, err := readSegment(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			if !seen[rec.Run] {
+				seen[rec.Run] = true
+				runs = append(runs, rec.Run)
+				segmentRuns[path] = append(segmentRuns[path], rec.Run)
+			}
+			replaced[path] += len(rec.Value)
+		}
+		for _, rec := range latest(records) {
+			replaced[path] -= len(rec.Value)
+		}
+	}
+	created := make(map[string]time.Time, len(runs))
+	for _, run := range runs {
+		created[run], _ = RunTime(run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !created[runs[i]].Equal(created[runs[j]]) {
+			return created[runs[i]].After(created[runs[j]])
+		}
+		return runs[i] > runs[j]
+	})
+
+	drop := make(map[string]bool)
+	var dropped []string
+	for i, run := range runs {
+		created := created[run]
+		if r.MaxRuns > 0 && i >= r.MaxRuns || r.MaxAge > 0 && !created.IsZero() && now.Sub(created) > r.MaxAge {
+			if keep != nil && keep(run) {
+				continue
+			}
+			drop[run] = true
+			dropped = append(dropped, run)
+		}
+	}
+
+	for _, path := range paths {
+		stale := replaced[path] >= compactSize
+		for _, run := range segmentRuns[path] {
+			stale = stale || drop[run]
+		}
+		if !stale {
+			continue
+		}
+		if err := rewrite(path, func(records []Record) []Record {
+			var kept []Record
+			for _, rec := range latest(records) {
+				if !drop[rec.Run] {
+					kept = append(kept, rec)
+				}
+			}
+			return kept
+		}); err != nil {
+			return dropped, err
+		}
+	}
+
+	if len(dropped) > 0 {
+		x_log.Info().
+			Int("runs", len(dropped)).
+			Str("dir", s.Dir).
+			Msg("pruned old runs")
+	}
+	return dropped, nil
+}
+
+// RunTime returns the creation time encoded in the ULID of a run.
+func RunTime(run string) (time.Time, bool) {
+	t, err := x_util.ULIDTime(run)
+	return t, err == nil
+}
+
+//
+// ---------- Segments ----------
+
+// segment returns the path of the segment holding the records of a run.
+func (s *Store) segment(run string) string {
+	day := "undated"
+	if t, ok := RunTime(run); ok {
+		day = t.UTC().Format("2006-01-02")
+	}
+	return filepath.Join(s.Dir, day+".jsonl")
+}
+
+// segments lists the segment files, newest first.
+func (s *Store) segments() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// readSegment reads the records of a segment in write order. A missing
+// segment has no records; lines that cannot be decoded are skipped.
+func readSegment(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open store segment: %w", err)
+	}
+	defer f.Close()
+
+	// A shared lock keeps out half-written appends
+	if err := x_lock.LockFile(f, false, true); err != nil {
+		return nil, fmt.Errorf("failed to lock store segment: %w", err)
+	}
+	return decode(f, path)
+}
+
+// decode parses JSONL records from f.
+func decode(f *os.File, path string) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			x_log.Warn().
+				Err(err).
+				Str("segment", path).
+				Msg("skipping corrupt store record")
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read store segment %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// rewrite replaces the records of a segment with keep(records) under an
+// exclusive lock. An empty result removes the segment.
+func rewrite(path string, keep func([]Record) []Record) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open store segment: %w", err)
+	}
+	defer f.Close()
+	if err := x_lock.LockFile(f, true, true); err != nil {
+		return fmt.Errorf("failed to lock store segment: %w", err)
+	}
+	if !sameFile(f, path) {
+		return nil // Rewritten by another process meanwhile
+	}
+
+	records, err := decode(f, path)
+	if err != nil {
+		return err
+	}
+	kept := keep(records)
+	if len(kept) == len(records) {
+		return nil
+	}
+	if len(kept) == 0 {
+		return os.Remove(path)
+	}
+
+	var buf bytes.Buffer
+	for _, r := range kept {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write store segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write store segment: %w", err)
+	}
+	return nil
+}
+
+// latest keeps the last record of every run and key, in order of first write.
+func latest(records []Record) []Record {
+	index := make(map[[2]string]int)
+	var out []Record
+	for _, r := range records {
+		k := [2]string{r.Run, r.Key}
+		if i, ok := index[k]; ok {
+			out[i] = r
+			continue
+		}
+		index[k] = len(out)
+		out = append(out, r)
+	}
+	return out
+}
+
+// sameFile checks that the open file is still the one at path.
+func sameFile(f *os.File, path string) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	b, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(a, b)
+}
@@ -0,0 +1,145 @@
+package x_store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_util"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestPutGet verifies that the latest record of a key wins.
+func TestPutGet(t *testing.T) {
+	s := New(t.TempDir())
+	run := x_util.NewULID()
+
+	for _, v := range []string{"pending", "running", "success"} {
+		if err := s.Put(run, "state", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got string
+	if err := s.Get(run, "state", &got); err != nil || got != "success" {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+	if err := s.Get(run, "missing", &got); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.Get(x_util.NewULID(), "state", &got); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown run, got %v", err)
+	}
+}
+
+// TestConcurrentPut verifies that parallel writers do not lose or tear records.
+func TestConcurrentPut(t *testing.T) {
+	s := New(t.TempDir())
+	run := x_util.NewULID()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.Put(run, fmt.Sprintf("result/%02d", i), strings.Repeat("x", 10000)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records, err := s.Records(run)
+	if err != nil || len(records) != 50 {
+		t.Fatalf("Records = %d records, %v", len(records), err)
+	}
+	if records[0].Key != "result/00" || records[49].Key != "result/49" {
+		t.Errorf("records not ordered by key: %s .. %s", records[0].Key, records[49].Key)
+	}
+}
+
+// TestScanPrune verifies listing across segments and retention by count and age.
+func TestScanPrune(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	now := time.Now()
+
+	old := x_util.NewULIDAt(now.AddDate(0, 0, -10))
+	mid := x_util.NewULIDAt(now.Add(-time.Hour))
+	recent := x_util.NewULIDAt(now)
+	for _, run := range []string{old, mid, recent} {
+		s.Put(run, "state", "pending")
+		s.Put(run, "state", "success")
+		s.Put(run, "result/a", "output")
+	}
+
+	states, err := s.Scan("state")
+	if err != nil || len(states) != 3 || states[0].Run != recent || states[2].Run != old {
+		t.Fatalf("Scan = %+v, %v", states, err)
+	}
+	if segments, _ := filepath.Glob(filepath.Join(dir, "*.jsonl")); len(segments) < 2 {
+		t.Errorf("expected one segment per day, got %v", segments)
+	}
+
+	keepOld := func(run string) bool { return run == old }
+	if dropped, err := s.Prune(Retention{MaxAge: 7 * 24 * time.Hour}, now, keepOld); err != nil || len(dropped) != 0 {
+		t.Fatalf("expected the kept run to survive, dropped %v, %v", dropped, err)
+	}
+	dropped, err := s.Prune(Retention{MaxAge: 7 * 24 * time.Hour}, now, nil)
+	if err != nil || len(dropped) != 1 || dropped[0] != old {
+		t.Fatalf("Prune by age dropped %v, %v", dropped, err)
+	}
+	dropped, err = s.Prune(Retention{MaxRuns: 1}, now, nil)
+	if err != nil || len(dropped) != 1 || dropped[0] != mid {
+		t.Fatalf("Prune by count dropped %v, %v", dropped, err)
+	}
+
+	// Only the records of the newest run remain
+	if records, err := s.Scan(""); err != nil || len(records) != 2 || records[0].Run != recent {
+		t.Errorf("expected the 2 records of the newest run, got %+v, %v", records, err)
+	}
+	if _, err := os.Stat(s.segment(old)); !os.IsNotExist(err) {
+		t.Errorf("expected the empty segment to be removed, got %v", err)
+	}
+}
+
+// TestPruneCompact verifies that segments are compacted only once the
+// records replaced in them pass compactSize.
+func TestPruneCompact(t *testing.T) {
+	s := New(t.TempDir())
+	run := x_util.NewULID()
+	lines := func() int {
+		data, _ := os.ReadFile(s.segment(run))
+		return strings.Count(string(data), "\n")
+	}
+
+	s.Put(run, "state", "pending")
+	s.Put(run, "state", "success")
+	if _, err := s.Prune(Retention{}, time.Now(), nil); err != nil || lines() != 2 {
+		t.Fatalf("expected the small segment to be left alone, got %d lines, %v", lines(), err)
+	}
+
+	for range 3 {
+		s.Put(run, "result/a", strings.Repeat("x", compactSize/2))
+	}
+	if _, err := s.Prune(Retention{}, time.Now(), nil); err != nil || lines() != 2 {
+		t.Errorf("expected 2 compacted records, got %d lines, %v", lines(), err)
+	}
+}
+
+// TestRunTime verifies the creation time read from run IDs.
+func TestRunTime(t *testing.T) {
+	created := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	if got, ok := RunTime(x_util.NewULIDAt(created)); !ok || !got.Equal(created) {
+		t.Errorf("RunTime = %v, %v", got, ok)
+	}
+	if _, ok := RunTime("nope"); ok {
+		t.Error("expected no time for an invalid ID")
+	}
+}
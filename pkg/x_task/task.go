@@ -2,41 +2,73 @@ package x_task
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/rskv-p/jtask/pkg/x_log"
 	"github.com/rskv-p/jtask/pkg/x_util"
 )
 
+//
+// ---------- Errors ----------
+
+// ErrTaskNotFound is returned by Resolve when a named task does not exist.
+var ErrTaskNotFound = errors.New("task not found")
+
 //
 // ---------- Data Structures ----------
 
 // TaskCollection represents a group of tasks loaded from a config file.
 type TaskCollection struct {
-	Name        string  `json:"name"`        // Collection name
-	Description string  `json:"description"` // Description of the task collection
-	Data        []*Task `json:"tasks"`       // List of tasks
+	Name        string  `json:"name"`                  // Collection name
+	Description string  `json:"description"`           // Description of the task collection
+	Concurrency string  `json:"concurrency,omitempty"` // Collection lock policy: skip, wait or fail
+	Data        []*Task `json:"tasks"`                 // List of tasks
 }
 
 // Task represents an individual task with execution settings.
 type Task struct {
-	IsAsync       bool     `json:"is_async"`        // Run in parallel
-	IsSudo        bool     `json:"is_sudo"`         // Run with sudo
-	IsPrintOutput bool     `json:"is_print_output"` // Capture and store output
-	Name          string   `json:"name"`            // Task name
-	Description   string   `json:"description"`     // Task description
-	Exec          []string `json:"exec"`            // Command to execute
+	IsAsync       bool     `json:"is_async"`               // Run in parallel
+	IsSudo        bool     `json:"is_sudo"`                // Run with sudo
+	IsPrintOutput bool     `json:"is_print_output"`        // Log the output and keep it in the run state
+	Name          string   `json:"name"`                   // Task name
+	Description   string   `json:"description"`            // Task description
+	Exec          []string `json:"exec"`                   // Command to execute
+	Tags          []string `json:"tags,omitempty"`         // Labels used by selectors such as tag:ci
+	Concurrency   string   `json:"concurrency,omitempty"`  // Task lock policy: skip, wait or fail
+	DependsOn     []string `json:"depends_on,omitempty"`   // Tasks that must succeed first
+	Schedule      string   `json:"schedule,omitempty"`     // Cron expression or "@every 10m" for jt daemon
+	Timezone      string   `json:"timezone,omitempty"`     // Time zone of the schedule (default local)
+	Overlap       string   `json:"overlap,omitempty"`      // Overlapping scheduled runs: skip, queue or replace
+	CatchUp       bool     `json:"catch_up,omitempty"`     // Run once after downtime if fires were missed
+	Jitter        string   `json:"jitter,omitempty"`       // Max random delay added to scheduled fires, e.g. "30s"
+	Watch         []string `json:"watch,omitempty"`        // Globs of files that re-run the task in jt watch
+	WatchIgnore   []string `json:"watch_ignore,omitempty"` // Gitignore-style patterns excluded from watching
+	Hidden        bool     `json:"hidden,omitempty"`       // Left out of prompts and jt list unless --all
+	Internal      bool     `json:"internal,omitempty"`     // Runs only as a dependency of other tasks
+	Artifacts     []string `json:"artifacts,omitempty"`    // Globs of files kept with the run after execution
 }
 
 // Result contains the result of a task execution.
 type Result struct {
-	ID          string `json:"id"`          // Unique task ID
-	Name        string `json:"name"`        // Task name
-	Description string `json:"description"` // Task description
-	Output      string `json:"output"`      // Captured output
+	ID          string    `json:"id"`              // Unique, time-sortable execution ID (ULID)
+	Name        string    `json:"name"`            // Task name
+	Description string    `json:"description"`     // Task description
+	Output      string    `json:"output"`          // Captured output
+	Started     time.Time `json:"started"`         // When the command started
+	Finished    time.Time `json:"finished"`        // When the command ended
+	ExitCode    int       `json:"exit_code"`       // Exit code, -1 if the command did not exit
+	Error       string    `json:"error,omitempty"` // Error of a failed execution
 }
 
 //
@@ -73,14 +105,34 @@ func LoadTasks(path string) (*TaskCollection, error) {
 	return tasks, nil
 }
 
-// ExecuteTask runs a single task and returns a result.
+// ExecuteTask runs a single task and returns a result. The result holds the
+// output of the task whether or not IsPrintOutput is set.
 func ExecuteTask(t *Task) (*Result, error) {
-	id, _ := x_util.RandomString(8)
+	return ExecuteTaskContext(context.Background(), t)
+}
 
+// ExecuteTaskContext runs a single task and kills it when ctx is cancelled.
+func ExecuteTaskContext(ctx context.Context, t *Task) (*Result, error) {
+	return ExecuteTaskStream(ctx, t, nil)
+}
+
+// ExecuteTaskStream runs a single task like ExecuteTaskContext and calls
+// onLine with every line the task writes to stdout or stderr, as it is
+// written. onLine may be nil; it is called from one goroutine per stream.
+func ExecuteTaskStream(ctx context.Context, t *Task, onLine func(stream, line string)) (*Result, error) {
+	return ExecuteTaskOutput(ctx, t, nil, onLine)
+}
+
+// ExecuteTaskOutput runs a single task like ExecuteTaskStream and also
+// copies its raw output, both streams in the order they are written, to
+// out as it is written. out may be nil; errors writing to it are ignored
+// so that they do not fail the task.
+func ExecuteTaskOutput(ctx context.Context, t *Task, out io.Writer, onLine func(stream, line string)) (*Result, error) {
 	result := &Result{
-		ID:          fmt.Sprintf("(async) %s", id),
+		ID:          x_util.NewULID(),
 		Name:        t.Name,
 		Description: t.Description,
+		Started:     time.Now(),
 	}
 
 	// Log the start of task execution
@@ -103,21 +155,32 @@ func ExecuteTask(t *Task) (*Result, error) {
 			Err(err).
 			Str("task", t.Name).
 			Msg("cannot execute task")
+		result.finish(err)
 		result.Output = err.Error()
 		return result, err
 	}
 
 	// Run the task command
 	var stdOut bytes.Buffer
-	var cmd *exec.Cmd
-	if t.IsSudo {
-		cmd = exec.Command("sudo", t.Exec...)
-	} else {
-		cmd = exec.Command(t.Exec[0], t.Exec[1:]...)
+	argv := t.Command()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+
+	combined := io.Writer(&stdOut)
+	if out != nil {
+		combined = io.MultiWriter(&stdOut, &ignoreErrors{w: out})
 	}
+	cmd.Stdout = combined
+	cmd.Stderr = combined
 
-	cmd.Stdout = &stdOut
-	cmd.Stderr = &stdOut
+	// Streamed output still lands in one buffer, in the order it is written
+	if onLine != nil {
+		var mu sync.Mutex
+		stdout := &lineWriter{mu: &mu, buf: combined, stream: "stdout", onLine: onLine}
+		stderr := &lineWriter{mu: &mu, buf: combined, stream: "stderr", onLine: onLine}
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		defer stdout.flush()
+		defer stderr.flush()
+	}
 
 	// Run the command and check for errors
 	if err := cmd.Run(); err != nil {
@@ -126,27 +189,225 @@ func ExecuteTask(t *Task) (*Result, error) {
 			Err(err).
 			Str("task", t.Name).
 			Msg("task execution failed")
+		result.finish(err)
 		result.Output = stdOut.String()
 		return result, err
 	}
 
-	// Capture and log task output if configured
-	if t.IsPrintOutput {
-		result.Output = stdOut.String()
-		x_log.Debug().
-			Str("task", t.Name).
-			Int("output_len", len(result.Output)).
-			Msg("task output captured")
-	}
+	// Capture task output for the result; print_output decides whether it is shown
+	result.Output = stdOut.String()
+	x_log.Debug().
+		Str("task", t.Name).
+		Int("output_len", len(result.Output)).
+		Msg("task output captured")
 
 	// Log task completion
 	x_log.Info().
 		Str("task", t.Name).
 		Msg("task completed successfully")
 
+	result.finish(nil)
 	return result, nil
 }
 
+// finish records the end of an execution and its exit code.
+func (r *Result) finish(err error) {
+	r.Finished = time.Now()
+	if err == nil {
+		return
+	}
+	r.Error = err.Error()
+	r.ExitCode = -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		r.ExitCode = exitErr.ExitCode()
+	}
+}
+
+// lineWriter copies the output of one stream into a shared buffer and
+// reports every complete line.
+type lineWriter struct {
+	mu      *sync.Mutex               // Guards buf, which both streams write to
+	buf     io.Writer                 // Captured output of the task
+	stream  string                    // "stdout" or "stderr"
+	onLine  func(stream, line string) // Called with every line
+	partial []byte                    // Start of a line not yet terminated
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.buf.Write(p)
+	w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(w.stream, strings.TrimSuffix(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush reports a last line without a newline.
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.onLine(w.stream, strings.TrimSuffix(string(w.partial), "\r"))
+		w.partial = nil
+	}
+}
+
+// ignoreErrors writes to w until the first error and drops what follows.
+type ignoreErrors struct {
+	w      io.Writer // Destination
+	failed bool      // Whether a write failed
+}
+
+func (w *ignoreErrors) Write(p []byte) (int, error) {
+	if !w.failed {
+		if _, err := w.w.Write(p); err != nil {
+			w.failed = true
+			x_log.Warn().Err(err).Msg("failed to copy task output")
+		}
+	}
+	return len(p), nil
+}
+
+// Command returns the argv the task runs, wrapped in sudo if configured.
+func (t *Task) Command() []string {
+	if t.IsSudo {
+		return append([]string{"sudo"}, t.Exec...)
+	}
+	return t.Exec
+}
+
+// Hash returns a stable fingerprint of the parts of the task definition
+// that affect its execution: command, sudo, dependencies and lock policy.
+// Descriptions, tags, schedules, watch globs and the like are left out.
+func (t *Task) Hash() string {
+	data, _ := json.Marshal(struct {
+		Exec        []string `json:"exec"`
+		IsSudo      bool     `json:"is_sudo"`
+		DependsOn   []string `json:"depends_on"`
+		Concurrency string   `json:"concurrency"`
+	}{t.Exec, t.IsSudo, t.DependsOn, t.Concurrency})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Find returns a task by its name, or nil if it does not exist.
+func (c *TaskCollection) Find(name string) *Task {
+	for _, t := range c.Data {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Resolve returns the named tasks together with everything they depend on,
+// ordered so that every task comes after its dependencies.
+func (c *TaskCollection) Resolve(names []string) ([]*Task, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int)
+	var ordered []*Task
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path, " -> "), name)
+		}
+
+		t := c.Find(name)
+		if t == nil {
+			if len(path) == 0 {
+				return fmt.Errorf("%w: %q", ErrTaskNotFound, name)
+			}
+			return fmt.Errorf("task %q depends on unknown task %q", path[len(path)-1], name)
+		}
+
+		marks[name] = visiting
+		for _, dep := range t.DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		ordered = append(ordered, t)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			x_log.Error().
+				Err(err).
+				Str("task", name).
+				Msg("failed to resolve task dependencies")
+			return nil, err
+		}
+	}
+
+	x_log.Debug().
+		Int("selected", len(names)).
+		Int("resolved", len(ordered)).
+		Msg("task dependencies resolved")
+	return ordered, nil
+}
+
+// WithDependents returns the named tasks and every task that depends on them,
+// directly or transitively, ordered so that every task comes after its
+// dependencies. Other dependencies of those tasks are not included.
+func (c *TaskCollection) WithDependents(names []string) ([]*Task, error) {
+	include := make(map[string]bool)
+	for _, name := range names {
+		include[name] = true
+	}
+
+	// Grow the set until no task depends on a member that is not in it
+	for changed := true; changed; {
+		changed = false
+		for _, t := range c.Data {
+			if include[t.Name] {
+				continue
+			}
+			for _, dep := range t.DependsOn {
+				if include[dep] {
+					include[t.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var all []string
+	for _, t := range c.Data {
+		if include[t.Name] {
+			all = append(all, t.Name)
+		}
+	}
+	resolved, err := c.Resolve(all)
+	if err != nil {
+		return nil, err
+	}
+
+	var ordered []*Task
+	for _, t := range resolved {
+		if include[t.Name] {
+			ordered = append(ordered, t)
+		}
+	}
+	return ordered, nil
+}
+
 //
 // ---------- Helper Functions ----------
 
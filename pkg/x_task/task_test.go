@@ -1,7 +1,12 @@
 package x_task
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -104,3 +109,147 @@ func TestExecuteTask(t *testing.T) {
 		t.Errorf("expected output %q, got %q", expected, result.Output)
 	}
 }
+
+// TestExecuteTaskStream verifies that lines of both streams are reported and
+// still captured in the result.
+func TestExecuteTaskStream(t *testing.T) {
+	task := &Task{Name: "streams", Exec: []string{"sh", "-c", "echo one; echo two >&2; printf three"}}
+
+	var mu sync.Mutex
+	lines := make(map[string][]string)
+	result, err := ExecuteTaskStream(context.Background(), task, func(stream, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines[stream] = append(lines[stream], line)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteTaskStream returned error: %v", err)
+	}
+
+	if got := strings.Join(lines["stdout"], ","); got != "one,three" {
+		t.Errorf("stdout lines = %q", got)
+	}
+	if got := strings.Join(lines["stderr"], ","); got != "two" {
+		t.Errorf("stderr lines = %q", got)
+	}
+	// The streams are read concurrently, so only their own order is kept
+	for _, want := range []string{"one\n", "two\n", "three"} {
+		if !strings.Contains(result.Output, want) || len(result.Output) != len("one\ntwo\nthree") {
+			t.Errorf("unexpected output %q", result.Output)
+		}
+	}
+}
+
+// TestExecuteTaskOutput verifies that raw output is copied as written.
+func TestExecuteTaskOutput(t *testing.T) {
+	task := &Task{Name: "raw", Exec: []string{"sh", "-c", "printf 'a\\r\\n'; echo b >&2; exit 2"}}
+
+	var out bytes.Buffer
+	result, err := ExecuteTaskOutput(context.Background(), task, &out, nil)
+	if err == nil || result.ExitCode != 2 {
+		t.Fatalf("expected exit code 2, got %+v, %v", result, err)
+	}
+	if out.String() != result.Output || !strings.Contains(out.String(), "a\r\n") {
+		t.Errorf("raw output %q does not match the result %q", out.String(), result.Output)
+	}
+}
+
+// TestResolve verifies that dependencies are included and ordered before their dependents.
+func TestResolve(t *testing.T) {
+	c := &TaskCollection{Data: []*Task{
+		{Name: "deploy", DependsOn: []string{"build", "test"}},
+		{Name: "test", DependsOn: []string{"build"}},
+		{Name: "build"},
+		{Name: "lint"},
+	}}
+
+	tasks, err := c.Resolve([]string{"deploy"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	expected := []string{"build", "test", "deploy"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected order %v, got %v", expected, names)
+	}
+}
+
+// TestResolveErrors checks unknown tasks, dangling dependencies and cycles.
+func TestResolveErrors(t *testing.T) {
+	c := &TaskCollection{Data: []*Task{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+		{Name: "c", DependsOn: []string{"missing"}},
+	}}
+
+	for _, name := range []string{"a", "c", "nope"} {
+		_, err := c.Resolve([]string{name})
+		if err == nil {
+			t.Errorf("expected error resolving %q", name)
+		}
+		// Only a missing named task is a bad selection
+		if notFound := errors.Is(err, ErrTaskNotFound); notFound != (name == "nope") {
+			t.Errorf("unexpected ErrTaskNotFound for %q: %v", name, err)
+		}
+	}
+}
+
+// TestHash verifies that the hash changes with the execution of a task
+// and not with its metadata.
+func TestHash(t *testing.T) {
+	a := &Task{Name: "build", Exec: []string{"go", "build"}}
+	b := &Task{Name: "build", Exec: []string{"go", "build"}}
+	if a.Hash() != b.Hash() {
+		t.Error("expected equal hashes for equal tasks")
+	}
+
+	b.Description, b.Tags, b.Hidden = "Build it", []string{"ci"}, true
+	b.Watch, b.Artifacts = []string{"**/*.go"}, []string{"bin/*"}
+	if a.Hash() != b.Hash() {
+		t.Error("expected metadata to leave the hash alone")
+	}
+	for _, change := range []func(*Task){
+		func(t *Task) { t.IsSudo = true },
+		func(t *Task) { t.DependsOn = []string{"gen"} },
+		func(t *Task) { t.Concurrency = "skip" },
+	} {
+		c := *a
+		change(&c)
+		if c.Hash() == a.Hash() {
+			t.Errorf("expected a different hash for %+v", c)
+		}
+	}
+
+	b.Exec = append(b.Exec, "./...")
+	if a.Hash() == b.Hash() {
+		t.Error("expected different hashes after changing exec")
+	}
+}
+
+// TestWithDependents verifies that dependents are added in dependency order.
+func TestWithDependents(t *testing.T) {
+	c := &TaskCollection{Data: []*Task{
+		{Name: "deploy", DependsOn: []string{"test", "assets"}},
+		{Name: "test", DependsOn: []string{"build"}},
+		{Name: "build", DependsOn: []string{"generate"}},
+		{Name: "generate"},
+		{Name: "assets"},
+	}}
+
+	tasks, err := c.WithDependents([]string{"build"})
+	if err != nil {
+		t.Fatalf("WithDependents returned error: %v", err)
+	}
+
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	if strings.Join(names, ",") != "build,test,deploy" {
+		t.Errorf("unexpected tasks: %v", names)
+	}
+}
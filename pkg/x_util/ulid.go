@@ -0,0 +1,94 @@
+package x_util
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLen is the length of an encoded ULID.
+const ulidLen = 26
+
+// last remembers the previous ULID so that IDs of one millisecond stay ordered.
+var last struct {
+	sync.Mutex
+	ms      int64
+	entropy [10]byte
+}
+
+// NewULID returns a ULID: 26 characters holding the creation time in
+// milliseconds followed by 80 random bits. ULIDs sort by creation time, and
+// IDs created within the same millisecond by this process sort in order.
+func NewULID() string {
+	return NewULIDAt(time.Now())
+}
+
+// NewULIDAt returns a ULID for time t.
+func NewULIDAt(t time.Time) string {
+	last.Lock()
+	defer last.Unlock()
+
+	ms := t.UnixMilli()
+	if ms == last.ms {
+		// Same millisecond: increment the entropy to keep the order
+		for i := len(last.entropy) - 1; i >= 0; i-- {
+			last.entropy[i]++
+			if last.entropy[i] != 0 {
+				break
+			}
+		}
+	} else {
+		last.ms = ms
+		rand.Read(last.entropy[:])
+	}
+
+	var data [16]byte
+	for i := 0; i < 6; i++ {
+		data[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(data[6:], last.entropy[:])
+	return encodeULID(data)
+}
+
+// encodeULID encodes 128 bits as 26 base32 characters, most significant first.
+func encodeULID(data [16]byte) string {
+	out := make([]byte, ulidLen)
+	// The 130 bits of output start with two zero bits
+	for i := 0; i < ulidLen; i++ {
+		bit := i*5 - 2
+		var v byte
+		for j := 0; j < 5; j++ {
+			if b := bit + j; b >= 0 && data[b/8]&(0x80>>(b%8)) != 0 {
+				v |= 0x10 >> j
+			}
+		}
+		out[i] = crockford[v]
+	}
+	return string(out)
+}
+
+// ULIDTime returns the creation time encoded in a ULID.
+func ULIDTime(id string) (time.Time, error) {
+	if len(id) != ulidLen {
+		return time.Time{}, fmt.Errorf("invalid ULID %q: want %d characters", id, ulidLen)
+	}
+	var ms int64
+	for _, c := range strings.ToUpper(id[:10]) {
+		v := strings.IndexRune(crockford, c)
+		if v < 0 {
+			return time.Time{}, fmt.Errorf("invalid ULID %q: bad character %q", id, c)
+		}
+		ms = ms<<5 | int64(v)
+	}
+	for _, c := range strings.ToUpper(id[10:]) {
+		if !strings.ContainsRune(crockford, c) {
+			return time.Time{}, fmt.Errorf("invalid ULID %q: bad character %q", id, c)
+		}
+	}
+	return time.UnixMilli(ms), nil
+}
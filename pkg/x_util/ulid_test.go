@@ -0,0 +1,44 @@
+package x_util
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestULID verifies the encoding, the embedded time and the ordering of ULIDs.
+func TestULID(t *testing.T) {
+	// Known vector: time 1469918176385, all-zero entropy
+	var data [16]byte
+	for i, b := range []byte{0x01, 0x56, 0x3D, 0xF3, 0x64, 0x81} {
+		data[i] = b
+	}
+	if got := encodeULID(data); got != "01ARYZ6S410000000000000000" {
+		t.Errorf("encodeULID = %s", got)
+	}
+
+	now := time.Now()
+	var ids []string
+	for i := 0; i < 100; i++ {
+		ids = append(ids, NewULIDAt(now))
+	}
+	if !sort.StringsAreSorted(ids) || ids[0] == ids[1] {
+		t.Errorf("ULIDs of one millisecond are not ordered: %v", ids[:3])
+	}
+	if later := NewULID(); later <= ids[len(ids)-1] {
+		t.Errorf("later ULID %s sorts before %s", later, ids[len(ids)-1])
+	}
+
+	got, err := ULIDTime(ids[0])
+	if err != nil || got.UnixMilli() != now.UnixMilli() {
+		t.Errorf("ULIDTime = %v, %v; want %v", got, err, now)
+	}
+	for _, bad := range []string{"", "01ARYZ6S41", "01ARYZ6S41000000000000000U"} {
+		if _, err := ULIDTime(bad); err == nil {
+			t.Errorf("ULIDTime(%q): expected error", bad)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package x_util
 
 import (
+	"strings"
 	"testing"
 	"unicode"
 )
@@ -44,3 +45,31 @@ func TestRandomStringZeroLength(t *testing.T) {
 		t.Errorf("Expected empty string, got %q", str)
 	}
 }
+
+// TestSplitJoinArgs verifies shell-style splitting and quoting round trips.
+func TestSplitJoinArgs(t *testing.T) {
+	cases := map[string][]string{
+		`go test ./...`:             {"go", "test", "./..."},
+		`sh -c 'echo "a b"; ls'`:    {"sh", "-c", `echo "a b"; ls`},
+		`echo "x \"y\" $HOME" z\ w`: {"echo", `x "y" $HOME`, "z w"},
+		`printf ''`:                 {"printf", ""},
+	}
+	for line, want := range cases {
+		got, err := SplitArgs(line)
+		if err != nil {
+			t.Fatalf("SplitArgs(%q) error: %v", line, err)
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") || len(got) != len(want) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", line, got, want)
+		}
+
+		back, err := SplitArgs(JoinArgs(want))
+		if err != nil || strings.Join(back, "|") != strings.Join(want, "|") || len(back) != len(want) {
+			t.Errorf("round trip of %q via %q = %q (%v)", want, JoinArgs(want), back, err)
+		}
+	}
+
+	if _, err := SplitArgs(`echo 'oops`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
@@ -0,0 +1,140 @@
+package x_validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+//
+// ---------- Output Formats ----------
+
+// Formats lists the supported output formats.
+var Formats = []string{"text", "json", "sarif"}
+
+// Write prints diagnostics in the given format: text, json or sarif.
+func Write(w io.Writer, format string, diags []Diagnostic) error {
+	switch format {
+	case "", "text":
+		return writeText(w, diags)
+	case "json":
+		return writeJSON(w, diags)
+	case "sarif":
+		return writeSARIF(w, diags)
+	default:
+		return fmt.Errorf("unknown format %q: use text, json or sarif", format)
+	}
+}
+
+// writeText prints one diagnostic per line, followed by a summary.
+func writeText(w io.Writer, diags []Diagnostic) error {
+	var errs, warns int
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			errs++
+		} else {
+			warns++
+		}
+		if _, err := fmt.Fprintln(w, d); err != nil {
+			return err
+		}
+	}
+	if len(diags) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found.")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d error(s), %d warning(s)\n", errs, warns)
+	return err
+}
+
+// writeJSON prints the diagnostics as a JSON array.
+func writeJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diags)
+}
+
+// SARIF 2.1.0 subset understood by GitHub code scanning and editors.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine   int `json:"startLine"`
+				StartColumn int `json:"startColumn"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	}
+)
+
+// writeSARIF prints the diagnostics as a SARIF 2.1.0 log.
+func writeSARIF(w io.Writer, diags []Diagnostic) error {
+	ids := make([]string, 0, len(Rules))
+	for id := range Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	driver := sarifDriver{Name: "jt"}
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: Rules[id]}})
+	}
+
+	results := []sarifResult{}
+	for _, d := range diags {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(d.File)
+		loc.PhysicalLocation.Region.StartLine = d.Line
+		loc.PhysicalLocation.Region.StartColumn = d.Column
+		results = append(results, sarifResult{
+			RuleID:    d.Rule,
+			Level:     string(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
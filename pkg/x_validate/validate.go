@@ -0,0 +1,409 @@
+package x_validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_cron"
+	"github.com/rskv-p/jtask/pkg/x_lock"
+	"github.com/rskv-p/jtask/pkg/x_log"
+	"github.com/rskv-p/jtask/pkg/x_parser"
+	"github.com/rskv-p/jtask/pkg/x_task"
+)
+
+//
+// ---------- Diagnostics ----------
+
+// Severity of a diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The tasks file is rejected
+	SeverityWarning Severity = "warning" // Suspicious, but the file is usable
+)
+
+// Diagnostic is a problem found at a position of a tasks file.
+type Diagnostic struct {
+	File     string   `json:"file"`     // Path of the tasks file
+	Line     int      `json:"line"`     // 1-based line
+	Column   int      `json:"column"`   // 1-based column in characters
+	Severity Severity `json:"severity"` // error or warning
+	Rule     string   `json:"rule"`     // Rule identifier, e.g. unknown-field
+	Message  string   `json:"message"`  // Human readable description
+
+	offset int64 // Byte offset, used for ordering
+}
+
+// String formats the diagnostic as file:line:column: severity: message [rule].
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Rules describes every rule identifier reported by Check.
+var Rules = map[string]string{
+	"syntax":             "The file is not valid JSON",
+	"type":               "A value has the wrong JSON type",
+	"unknown-field":      "A key is not a known field",
+	"key-case":           "A key matches a field only when case is ignored",
+	"duplicate-key":      "A key appears twice in the same object",
+	"missing-name":       "A task has no name",
+	"duplicate-name":     "Two tasks have the same name",
+	"empty-exec":         "A task has no command",
+	"missing-binary":     "The command of a task is not found in PATH",
+	"unknown-dependency": "depends_on names a task that does not exist",
+	"dependency-cycle":   "Tasks depend on each other in a cycle",
+	"invalid-setting":    "A setting such as a schedule or policy cannot be parsed",
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// ---------- Public Functions ----------
+
+// Validate reads and checks a tasks file.
+func Validate(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	diags := Check(path, data)
+
+	x_log.Debug().
+		Str("path", path).
+		Int("diagnostics", len(diags)).
+		Msg("tasks file validated")
+	return diags, nil
+}
+
+// Check statically analyses the content of a tasks file named file.
+// Diagnostics are ordered by position.
+func Check(file string, data []byte) []Diagnostic {
+	v := &validator{file: file, data: data}
+	v.check()
+	sort.SliceStable(v.diags, func(i, j int) bool { return v.diags[i].offset < v.diags[j].offset })
+	return v.diags
+}
+
+//
+// ---------- Validator ----------
+
+// validator collects diagnostics for one file.
+type validator struct {
+	file  string
+	data  []byte
+	diags []Diagnostic
+}
+
+// report adds a diagnostic at a byte offset.
+func (v *validator) report(offset int64, sev Severity, rule, format string, args ...any) {
+	line, col := x_parser.LineCol(v.data, offset)
+	v.diags = append(v.diags, Diagnostic{
+		File:     v.file,
+		Line:     line,
+		Column:   col,
+		Severity: sev,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+		offset:   offset,
+	})
+}
+
+// check runs all checks.
+func (v *validator) check() {
+	root, err := x_parser.ParseTree(v.data)
+	if err != nil {
+		var oe *x_parser.OffsetError
+		errors.As(err, &oe)
+		v.report(oe.Offset, SeverityError, "syntax", "%v", oe.Err)
+		return
+	}
+	if !root.IsObject() {
+		v.report(root.Offset, SeverityError, "type", "tasks file must be a JSON object")
+		return
+	}
+	v.checkObject(root, reflect.TypeOf(x_task.TaskCollection{}))
+
+	// Type errors were reported above; decode what is usable
+	var c x_task.TaskCollection
+	_ = json.Unmarshal(v.data, &c)
+
+	if m := root.Get("concurrency"); m != nil && c.Concurrency != "" {
+		if _, err := x_lock.ParsePolicy(c.Concurrency); err != nil {
+			v.report(m.Value.Offset, SeverityError, "invalid-setting", "%v", err)
+		}
+	}
+
+	list := root.Get("tasks")
+	if list == nil {
+		v.report(root.Offset, SeverityError, "type", "missing \"tasks\" array")
+		return
+	}
+	if !list.Value.IsArray() || len(list.Value.Items) != len(c.Data) {
+		return
+	}
+
+	nodes := make(map[string]*x_parser.Node)
+	for i, t := range c.Data {
+		n := list.Value.Items[i]
+		if t == nil || !n.IsObject() {
+			continue
+		}
+		v.checkTask(n, t)
+
+		if t.Name == "" {
+			continue
+		}
+		if _, dup := nodes[t.Name]; dup {
+			v.report(valueOffset(n, "name"), SeverityError, "duplicate-name", "duplicate task name %q", t.Name)
+			continue
+		}
+		nodes[t.Name] = n
+	}
+
+	v.checkDependencies(&c, nodes)
+}
+
+// checkTask checks the fields of a single task.
+func (v *validator) checkTask(n *x_parser.Node, t *x_task.Task) {
+	if t.Name == "" {
+		v.report(n.Offset, SeverityError, "missing-name", "task has no name")
+	}
+
+	if len(t.Exec) == 0 {
+		v.report(valueOffset(n, "exec"), SeverityError, "empty-exec", "task %q has an empty exec command", t.Name)
+	} else {
+		bins := []string{t.Exec[0]}
+		if t.IsSudo {
+			bins = []string{"sudo", t.Exec[0]}
+		}
+		for _, bin := range bins {
+			if bin == "" {
+				continue
+			}
+			if _, err := exec.LookPath(bin); err != nil {
+				v.report(itemOffset(n, "exec", 0), SeverityError, "missing-binary",
+					"command %q of task %q not found in PATH", bin, t.Name)
+			}
+		}
+	}
+
+	setting := func(key string, check func(string) error) {
+		m := n.Get(key)
+		if m == nil {
+			return
+		}
+		s, ok := m.Value.Value.(string)
+		if !ok || s == "" {
+			return
+		}
+		if err := check(s); err != nil {
+			v.report(m.Value.Offset, SeverityError, "invalid-setting", "%v", err)
+		}
+	}
+	setting("concurrency", func(s string) error {
+		_, err := x_lock.ParsePolicy(s)
+		return err
+	})
+	setting("schedule", func(s string) error {
+		_, err := x_cron.Parse(s, t.Timezone)
+		return err
+	})
+	setting("overlap", func(s string) error {
+		_, err := x_cron.ParseOverlap(s)
+		return err
+	})
+	setting("jitter", func(s string) error {
+		if d, err := time.ParseDuration(s); err != nil || d < 0 {
+			return fmt.Errorf("invalid jitter %q", s)
+		}
+		return nil
+	})
+}
+
+// checkDependencies reports unknown dependencies and cycles.
+func (v *validator) checkDependencies(c *x_task.TaskCollection, nodes map[string]*x_parser.Node) {
+	// depOffset returns the position of the i-th depends_on entry of a task
+	depOffset := func(name string, i int) int64 {
+		return itemOffset(nodes[name], "depends_on", i)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int)
+
+	var visit func(name string, path []string)
+	visit = func(name string, path []string) {
+		marks[name] = visiting
+		t := c.Find(name)
+		for i, dep := range t.DependsOn {
+			switch {
+			case nodes[dep] == nil:
+				v.report(depOffset(name, i), SeverityError, "unknown-dependency",
+					"task %q depends on unknown task %q", name, dep)
+			case marks[dep] == visiting:
+				full := append(append([]string{}, path...), name)
+				cycle := append(full[indexOf(full, dep):], dep)
+				v.report(depOffset(name, i), SeverityError, "dependency-cycle",
+					"dependency cycle: %s", strings.Join(cycle, " -> "))
+			case marks[dep] == 0:
+				visit(dep, append(path, name))
+			}
+		}
+		marks[name] = visited
+	}
+
+	for _, t := range c.Data {
+		if t != nil && nodes[t.Name] != nil && marks[t.Name] == 0 {
+			visit(t.Name, nil)
+		}
+	}
+}
+
+// checkObject reports unknown, duplicate and mistyped keys of an object
+// decoded into a struct of type typ.
+func (v *validator) checkObject(n *x_parser.Node, typ reflect.Type) {
+	fields := jsonFields(typ)
+	seen := make(map[string]bool)
+	for _, m := range n.Members {
+		// Keys that differ only in case set the same field
+		if seen[strings.ToLower(m.Key)] {
+			v.report(m.Offset, SeverityWarning, "duplicate-key", "duplicate key %q, the last one wins", m.Key)
+		}
+		seen[strings.ToLower(m.Key)] = true
+
+		name, ft, ok := field(fields, m.Key)
+		if !ok {
+			v.report(m.Offset, SeverityError, "unknown-field", "unknown field %q", m.Key)
+			continue
+		}
+		if name != m.Key {
+			v.report(m.Offset, SeverityWarning, "key-case", "key %q is read as field %q", m.Key, name)
+		}
+		v.checkType(m.Value, ft, m.Key)
+	}
+}
+
+// checkType reports a value that cannot be decoded into typ.
+func (v *validator) checkType(n *x_parser.Node, typ reflect.Type, key string) {
+	if n.Value == nil {
+		return // null is accepted for every type
+	}
+
+	var ok bool
+	var want string
+	switch typ.Kind() {
+	case reflect.Pointer:
+		v.checkType(n, typ.Elem(), key)
+		return
+	case reflect.Bool:
+		_, ok = n.Value.(bool)
+		want = "a boolean"
+	case reflect.String:
+		_, ok = n.Value.(string)
+		want = "a string"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		_, ok = n.Value.(json.Number)
+		want = "a number"
+	case reflect.Slice:
+		if ok, want = n.IsArray(), "an array"; ok {
+			for _, item := range n.Items {
+				v.checkType(item, typ.Elem(), key)
+			}
+		}
+	case reflect.Struct:
+		if ok, want = n.IsObject(), "an object"; ok {
+			v.checkObject(n, typ)
+		}
+	default:
+		return
+	}
+	if !ok {
+		v.report(n.Offset, SeverityError, "type", "%q must be %s", key, want)
+	}
+}
+
+//
+// ---------- Helper Functions ----------
+
+// jsonFields maps the JSON names of a struct's fields to their types.
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// field looks up a key in the fields of jsonFields. Like encoding/json it
+// prefers an exact match and otherwise ignores case.
+func field(fields map[string]reflect.Type, key string) (string, reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return key, ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return name, ft, true
+		}
+	}
+	return "", nil, false
+}
+
+// valueOffset returns the position of the value of key in an object, or of
+// the object if the key is missing.
+func valueOffset(n *x_parser.Node, key string) int64 {
+	if m := n.Get(key); m != nil {
+		return m.Value.Offset
+	}
+	return n.Offset
+}
+
+// itemOffset returns the position of the i-th item of the array under key
+// in an object, falling back to the value or the object.
+func itemOffset(n *x_parser.Node, key string, i int) int64 {
+	m := n.Get(key)
+	if m == nil {
+		return n.Offset
+	}
+	if i < len(m.Value.Items) {
+		return m.Value.Items[i].Offset
+	}
+	return m.Value.Offset
+}
+
+// indexOf returns the index of s in list, or 0 if it is missing.
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return 0
+}
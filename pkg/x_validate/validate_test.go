@@ -0,0 +1,140 @@
+package x_validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// rulesAt returns "line:col rule" for every diagnostic.
+func rulesAt(diags []Diagnostic) []string {
+	var out []string
+	for _, d := range diags {
+		out = append(out, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Rule))
+	}
+	return out
+}
+
+// TestCheck verifies rules and positions on a broken tasks file.
+func TestCheck(t *testing.T) {
+	data := []byte(`{
+  "name": "bad",
+  "tasks": [
+    { "name": "a", "exec": ["true"], "depends_on": ["b"], "colour": "red" },
+    { "name": "b", "exec": [], "depends_on": ["a", "zzz"] },
+    { "name": "a", "exec": ["no-such-binary-for-jt"], "is_async": "yes" },
+    { "name": "c", "exec": ["true"], "depends_on": ["c"], "jitter": "-1s" }
+  ]
+}`)
+
+	got := rulesAt(Check("tasks.json", data))
+	want := []string{
+		"4:59 unknown-field",
+		"5:28 empty-exec",
+		"5:47 dependency-cycle",
+		"5:52 unknown-dependency",
+		"6:15 duplicate-name",
+		"6:29 missing-binary",
+		"6:67 type",
+		"7:53 dependency-cycle",
+		"7:69 invalid-setting",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestCheckKeyCase verifies that keys in another case are read like
+// encoding/json reads them, with positions of their values.
+func TestCheckKeyCase(t *testing.T) {
+	data := []byte(`{
+  "Name": "case",
+  "Tasks": [
+    { "Name": "a", "Exec": ["no-such-binary-for-jt"] },
+    { "NAME": "a", "EXEC": ["true"], "Depends_On": ["zzz"] },
+    { "name": "b", "exec": [], "depends_on": ["b"], "Depends_On": ["b"] }
+  ]
+}`)
+
+	got := rulesAt(Check("tasks.json", data))
+	want := []string{
+		"2:3 key-case",
+		"3:3 key-case",
+		"4:7 key-case",
+		"4:20 key-case",
+		"4:29 missing-binary",
+		"5:7 key-case",
+		"5:15 duplicate-name",
+		"5:20 key-case",
+		"5:38 key-case",
+		"6:28 empty-exec",
+		"6:53 duplicate-key",
+		"6:53 key-case",
+		"6:68 dependency-cycle",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestCheckSyntax verifies that syntax errors point at the offending character.
+func TestCheckSyntax(t *testing.T) {
+	diags := Check("tasks.json", []byte("{\n  \"tasks\": [,]\n}"))
+	if got := rulesAt(diags); len(got) != 1 || got[0] != "2:13 syntax" {
+		t.Errorf("diagnostics = %v", got)
+	}
+
+	diags = Check("tasks.json", []byte(`{"tasks": [`))
+	if len(diags) != 1 || diags[0].Rule != "syntax" {
+		t.Errorf("truncated file: %v", diags)
+	}
+}
+
+// TestCheckValid verifies that a valid file has no diagnostics.
+func TestCheckValid(t *testing.T) {
+	data := []byte(`{"name": "ok", "tasks": [
+		{"name": "build", "exec": ["true"], "tags": ["ci"], "schedule": "@daily"},
+		{"name": "test", "exec": ["true"], "depends_on": ["build"], "concurrency": "skip"}
+	]}`)
+	if diags := Check("tasks.json", data); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+// TestWrite verifies the json and sarif formats.
+func TestWrite(t *testing.T) {
+	diags := Check("dir/tasks.json", []byte(`{"tasks": [{"name": "a", "exec": []}]}`))
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "json", diags); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Diagnostic
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0].Rule != "empty-exec" {
+		t.Errorf("json output = %s (%v)", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "sarif", diags); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	res := log.Runs[0].Results
+	if log.Version != "2.1.0" || len(res) != 1 || res[0].Level != "error" ||
+		res[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != "dir/tasks.json" ||
+		res[0].Locations[0].PhysicalLocation.Region.StartColumn != 34 {
+		t.Errorf("sarif output = %s", buf.String())
+	}
+
+	if err := Write(&buf, "xml", diags); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,155 @@
+package x_watch
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+)
+
+//
+// ---------- Inotify Backend ----------
+
+// inotifyMask selects the events that indicate a content change.
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotify watches every directory under the roots with Linux inotify.
+type inotify struct {
+	w    *Watcher
+	fd   int      // Raw descriptor for InotifyAddWatch, which never goes through file.Fd
+	file *os.File // Non-blocking reads that Close interrupts
+	out  chan string
+	stop chan struct{}
+
+	mu  sync.Mutex
+	wds map[int32]string // Watch descriptor to directory relative to the root
+}
+
+// newInotify starts the inotify backend.
+func newInotify(w *Watcher) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	// A non-blocking fd lets the runtime poller interrupt reads on Close
+	in := &inotify{
+		w:    w,
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		out:  make(chan string, 64),
+		stop: make(chan struct{}),
+		wds:  make(map[int32]string),
+	}
+
+	var added int
+	w.walk(func(rel string, d fs.DirEntry) {
+		if d.IsDir() && in.add(rel) == nil {
+			added++
+		}
+	})
+	if added == 0 {
+		in.file.Close()
+		return nil, errors.New("no directories to watch")
+	}
+
+	x_log.Debug().
+		Int("dirs", added).
+		Msg("watching directories with inotify")
+	go in.loop()
+	return in, nil
+}
+
+func (in *inotify) events() <-chan string { return in.out }
+func (in *inotify) close() {
+	close(in.stop)
+	in.file.Close()
+}
+
+// emit sends an event unless the backend is stopping.
+func (in *inotify) emit(rel string) {
+	select {
+	case in.out <- rel:
+	case <-in.stop:
+	}
+}
+
+// add starts watching a directory relative to the root.
+func (in *inotify) add(rel string) error {
+	wd, err := syscall.InotifyAddWatch(in.fd, filepath.Join(in.w.opts.Root, rel), inotifyMask)
+	if err != nil {
+		return err
+	}
+	in.mu.Lock()
+	in.wds[int32(wd)] = rel
+	in.mu.Unlock()
+	return nil
+}
+
+// loop reads and decodes events until the file is closed.
+func (in *inotify) loop() {
+	defer close(in.out)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			name := strings.TrimRight(string(nameBytes), "\x00")
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			in.handle(ev, name)
+		}
+	}
+}
+
+// handle turns one inotify event into changed paths.
+func (in *inotify) handle(ev *syscall.InotifyEvent, name string) {
+	in.mu.Lock()
+	dir, ok := in.wds[ev.Wd]
+	if ev.Mask&syscall.IN_IGNORED != 0 {
+		delete(in.wds, ev.Wd)
+	}
+	in.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+
+	rel := filepath.Join(dir, name)
+	if ev.Mask&syscall.IN_ISDIR != 0 {
+		// Watch new directories and report the files already inside them
+		if ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 &&
+			!in.w.opts.Ignore.Ignored(filepath.ToSlash(rel), true) {
+			filepath.WalkDir(filepath.Join(in.w.opts.Root, rel), func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				sub, _ := filepath.Rel(in.w.opts.Root, p)
+				if d.IsDir() {
+					if in.w.opts.Ignore.Ignored(filepath.ToSlash(sub), true) {
+						return filepath.SkipDir
+					}
+					in.add(sub)
+					return nil
+				}
+				in.emit(sub)
+				return nil
+			})
+		}
+		return
+	}
+
+	in.emit(rel)
+}
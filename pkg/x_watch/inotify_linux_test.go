@@ -0,0 +1,38 @@
+package x_watch
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestInotifyClose verifies that the descriptor stays non-blocking while
+// directories are added, so that closing the backend ends its read loop
+// without waiting for another event.
+func TestInotifyClose(t *testing.T) {
+	w := &Watcher{opts: Options{Root: t.TempDir(), Patterns: []string{"**"}, Ignore: NewIgnore()}}
+	b, err := newInotify(w)
+	if err != nil {
+		t.Fatalf("newInotify returned error: %v", err)
+	}
+	in := b.(*inotify)
+
+	flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(in.fd), syscall.F_GETFL, 0)
+	if errno != 0 || flags&syscall.O_NONBLOCK == 0 {
+		t.Errorf("expected a non-blocking descriptor, got flags %#x, %v", flags, errno)
+	}
+
+	time.Sleep(50 * time.Millisecond) // Let the loop block in its read
+	in.close()
+	select {
+	case _, ok := <-in.events():
+		if ok {
+			t.Error("expected no events")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events not closed after close")
+	}
+}
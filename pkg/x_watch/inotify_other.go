@@ -0,0 +1,10 @@
+//go:build !linux
+
+package x_watch
+
+import "errors"
+
+// newInotify is only available on Linux; other systems use polling.
+func newInotify(w *Watcher) (backend, error) {
+	return nil, errors.New("inotify is not supported on this platform")
+}
@@ -0,0 +1,167 @@
+package x_watch
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//
+// ---------- Glob Matching ----------
+
+// Match reports whether a slash-separated path matches a glob pattern.
+// Besides the path.Match syntax, "**" matches any number of directories.
+func Match(pattern, name string) bool {
+	return matchParts(splitPath(pattern), splitPath(name))
+}
+
+// matchParts matches path segments against pattern segments.
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// "**" swallows zero or more segments
+			for i := 0; i <= len(name); i++ {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// splitPath splits a cleaned slash path into its segments.
+func splitPath(p string) []string {
+	p = strings.Trim(path.Clean(filepath.ToSlash(p)), "/")
+	if p == "" || p == "." {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// globRoot returns the directory part of a pattern before the first wildcard.
+func globRoot(pattern string) string {
+	parts := splitPath(pattern)
+	var root []string
+	for _, part := range parts[:max(len(parts)-1, 0)] {
+		if strings.ContainsAny(part, "*?[") {
+			break
+		}
+		root = append(root, part)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	return strings.Join(root, "/")
+}
+
+//
+// ---------- Ignore Rules ----------
+
+// ignoreRule is a single gitignore-style pattern.
+type ignoreRule struct {
+	pattern  string // Pattern without leading "!" or trailing "/"
+	negate   bool   // "!pattern" re-includes a path
+	dirOnly  bool   // "pattern/" matches directories only
+	anchored bool   // Pattern contains a slash and is matched from the root
+}
+
+// Ignore holds gitignore-style rules; later rules override earlier ones.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// NewIgnore parses gitignore-style patterns.
+func NewIgnore(patterns ...string) *Ignore {
+	ig := &Ignore{}
+	ig.Add(patterns...)
+	return ig
+}
+
+// Add appends gitignore-style patterns; blank lines and comments are skipped.
+func (ig *Ignore) Add(patterns ...string) {
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+
+		var r ignoreRule
+		if strings.HasPrefix(p, "!") {
+			r.negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			r.dirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			r.anchored = true
+			p = strings.TrimPrefix(p, "/")
+		}
+		r.pattern = p
+		ig.rules = append(ig.rules, r)
+	}
+}
+
+// AddFile reads patterns from a .gitignore file. A missing file is not an error.
+func (ig *Ignore) AddFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ig.Add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Ignored reports whether a slash path relative to the root is ignored.
+// A path inside an ignored directory is ignored too.
+func (ig *Ignore) Ignored(name string, isDir bool) bool {
+	parts := splitPath(name)
+	for i := 1; i <= len(parts); i++ {
+		dir := i < len(parts) || isDir
+		if ig.match(parts[:i], dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// match applies all rules to one path; the last matching rule wins.
+func (ig *Ignore) match(parts []string, isDir bool) bool {
+	ignored := false
+	for _, r := range ig.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		var ok bool
+		if r.anchored {
+			ok = Match(r.pattern, strings.Join(parts, "/"))
+		} else {
+			ok, _ = path.Match(r.pattern, parts[len(parts)-1])
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
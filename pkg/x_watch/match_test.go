@@ -0,0 +1,85 @@
+package x_watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//
+// ---------- Unit Tests ----------
+
+// TestMatch verifies glob matching with "**".
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/run.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "pkg/x_task/task.go", true},
+		{"pkg/**", "pkg/x_task/task.go", true},
+		{"pkg/**/*_test.go", "pkg/x_task/task.go", false},
+		{"pkg/**/*_test.go", "pkg/x_task/task_test.go", true},
+		{"./src/*.ts", "src/app.ts", true},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.name); got != c.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+// TestIgnore verifies gitignore-style rules.
+func TestIgnore(t *testing.T) {
+	ig := NewIgnore(
+		"# build output",
+		"*.log",
+		"!keep.log",
+		"node_modules/",
+		"/dist",
+		"docs/**/*.tmp",
+	)
+
+	cases := []struct {
+		name  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"node_modules", true, true},
+		{"web/node_modules/pkg/index.js", false, true},
+		{"node_modules", false, false},
+		{"dist/app.js", false, true},
+		{"web/dist/app.js", false, false},
+		{"docs/a/b/x.tmp", false, true},
+		{"main.go", false, false},
+	}
+	for _, c := range cases {
+		if got := ig.Ignored(c.name, c.isDir); got != c.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", c.name, c.isDir, got, c.want)
+		}
+	}
+}
+
+// TestIgnoreAddFile reads patterns from a .gitignore file.
+func TestIgnoreAddFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("vendor/\n*.out\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ig := NewIgnore()
+	if err := ig.AddFile(path); err != nil {
+		t.Fatalf("AddFile returned error: %v", err)
+	}
+	if !ig.Ignored("vendor/lib.go", false) || !ig.Ignored("cover.out", false) {
+		t.Error("expected patterns from file to apply")
+	}
+	if err := ig.AddFile(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("expected missing file to be ignored, got %v", err)
+	}
+}
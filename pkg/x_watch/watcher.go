@@ -0,0 +1,259 @@
+package x_watch
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rskv-p/jtask/pkg/x_log"
+)
+
+//
+// ---------- Options ----------
+
+// Options configures a Watcher.
+type Options struct {
+	Root         string        // Directory patterns are relative to
+	Patterns     []string      // Globs of files to watch
+	Ignore       *Ignore       // Paths to ignore (nil for none)
+	Debounce     time.Duration // Quiet period before reporting changes
+	Poll         bool          // Force the polling backend
+	PollInterval time.Duration // Polling interval of the polling backend
+}
+
+// backend reports raw changed paths relative to the root.
+type backend interface {
+	events() <-chan string
+	close()
+}
+
+//
+// ---------- Watcher ----------
+
+// Watcher reports debounced batches of changed files matching the patterns.
+type Watcher struct {
+	Changes <-chan []string // Batches of changed paths, relative to Root
+
+	opts    Options
+	backend backend
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New starts watching. It uses inotify where available and falls back to polling.
+func New(opts Options) (*Watcher, error) {
+	if opts.Root == "" {
+		opts.Root = "."
+	}
+	if opts.Ignore == nil {
+		opts.Ignore = NewIgnore()
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 200 * time.Millisecond
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if len(opts.Patterns) == 0 {
+		return nil, errors.New("no watch patterns given")
+	}
+
+	w := &Watcher{opts: opts, done: make(chan struct{})}
+
+	var err error
+	if !opts.Poll {
+		if w.backend, err = newInotify(w); err != nil {
+			x_log.Warn().
+				Err(err).
+				Msg("inotify unavailable, falling back to polling")
+		}
+	}
+	if w.backend == nil {
+		w.backend = newPoller(w)
+	}
+
+	changes := make(chan []string)
+	w.Changes = changes
+	go w.debounce(changes)
+	return w, nil
+}
+
+// Close stops the watcher and closes Changes.
+func (w *Watcher) Close() {
+	w.once.Do(func() {
+		close(w.done)
+		w.backend.close()
+	})
+}
+
+// roots returns the distinct directories to watch.
+func (w *Watcher) roots() []string {
+	seen := make(map[string]bool)
+	var roots []string
+	for _, p := range w.opts.Patterns {
+		root := globRoot(p)
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// wanted reports whether a relative path matches a pattern and is not ignored.
+func (w *Watcher) wanted(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if w.opts.Ignore.Ignored(rel, false) {
+		return false
+	}
+	for _, p := range w.opts.Patterns {
+		if Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls fn for every directory and file under the roots that is not ignored.
+func (w *Watcher) walk(fn func(rel string, d fs.DirEntry)) {
+	for _, root := range w.roots() {
+		abs := filepath.Join(w.opts.Root, root)
+		filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(w.opts.Root, p)
+			if rel != "." && w.opts.Ignore.Ignored(filepath.ToSlash(rel), d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			fn(rel, d)
+			return nil
+		})
+	}
+}
+
+// debounce batches matching events until the debounce period passes quietly.
+func (w *Watcher) debounce(out chan<- []string) {
+	defer close(out)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case rel, ok := <-w.backend.events():
+			if !ok {
+				return
+			}
+			if !w.wanted(rel) {
+				continue
+			}
+			pending[filepath.ToSlash(rel)] = true
+			timer.Reset(w.opts.Debounce)
+		case <-timer.C:
+			batch := make([]string, 0, len(pending))
+			for p := range pending {
+				batch = append(batch, p)
+			}
+			sort.Strings(batch)
+			pending = make(map[string]bool)
+
+			x_log.Debug().
+				Strs("files", batch).
+				Msg("files changed")
+			select {
+			case out <- batch:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+//
+// ---------- Polling Backend ----------
+
+// poller detects changes by comparing modification times and sizes.
+type poller struct {
+	w    *Watcher
+	out  chan string
+	stop chan struct{}
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// newPoller starts the polling backend.
+func newPoller(w *Watcher) *poller {
+	p := &poller{w: w, out: make(chan string, 64), stop: make(chan struct{})}
+	go p.loop()
+	x_log.Debug().
+		Dur("interval", w.opts.PollInterval).
+		Msg("polling for file changes")
+	return p
+}
+
+func (p *poller) events() <-chan string { return p.out }
+func (p *poller) close()                { close(p.stop) }
+
+// loop scans the tree every interval and emits added, changed and removed files.
+func (p *poller) loop() {
+	prev := p.scan()
+	ticker := time.NewTicker(p.w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cur := p.scan()
+		for rel, stamp := range cur {
+			if old, ok := prev[rel]; !ok || old != stamp {
+				p.emit(rel)
+			}
+		}
+		for rel := range prev {
+			if _, ok := cur[rel]; !ok {
+				p.emit(rel)
+			}
+		}
+		prev = cur
+	}
+}
+
+// scan records the stamp of every watched file.
+func (p *poller) scan() map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	p.w.walk(func(rel string, d fs.DirEntry) {
+		if d.IsDir() {
+			return
+		}
+		if info, err := d.Info(); err == nil {
+			files[rel] = fileStamp{mod: info.ModTime(), size: info.Size()}
+		}
+	})
+	return files
+}
+
+// emit sends an event unless the poller is stopping.
+func (p *poller) emit(rel string) {
+	select {
+	case p.out <- rel:
+	case <-p.stop:
+	}
+}
@@ -0,0 +1,83 @@
+package x_watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//
+// ---------- Helpers ----------
+
+// expectBatch waits for the next batch of changes.
+func expectBatch(t *testing.T, w *Watcher) []string {
+	t.Helper()
+	select {
+	case batch := <-w.Changes:
+		return batch
+	case <-time.After(3 * time.Second):
+		t.Fatal("no changes reported")
+		return nil
+	}
+}
+
+// testWatcher checks that matching changes are reported once, debounced,
+// and that ignored or unmatched files are not reported.
+func testWatcher(t *testing.T, poll bool) {
+	root := t.TempDir()
+	for _, dir := range []string{"src", "build"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New(Options{
+		Root:         root,
+		Patterns:     []string{"**/*.go"},
+		Ignore:       NewIgnore("build/"),
+		Debounce:     50 * time.Millisecond,
+		Poll:         poll,
+		PollInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	defer w.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	// Several writes within the debounce window form one batch
+	for _, name := range []string{"src/a.go", "src/b.go", "src/notes.txt", "build/gen.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batch := expectBatch(t, w)
+	if len(batch) != 2 || batch[0] != "src/a.go" || batch[1] != "src/b.go" {
+		t.Errorf("unexpected batch: %v", batch)
+	}
+
+	// Files in new directories are picked up too
+	if err := os.MkdirAll(filepath.Join(root, "src", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(root, "src", "sub", "c.go"), []byte("package x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	batch = expectBatch(t, w)
+	if len(batch) != 1 || batch[0] != "src/sub/c.go" {
+		t.Errorf("unexpected batch: %v", batch)
+	}
+}
+
+//
+// ---------- Unit Tests ----------
+
+// TestWatcherInotify exercises the default backend.
+func TestWatcherInotify(t *testing.T) { testWatcher(t, false) }
+
+// TestWatcherPoll exercises the polling fallback.
+func TestWatcherPoll(t *testing.T) { testWatcher(t, true) }